@@ -17,29 +17,639 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
-// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
-
 // AccountIAMSpec defines the desired state of AccountIAM
 type AccountIAMSpec struct {
-	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// AccountIAM configures the account-iam WebSphere Liberty application
+	// +optional
+	AccountIAM AccountIAMAppSpec `json:"accountIAM,omitempty"`
+
+	// Database configures the account_iam database and the jobs which bootstrap and migrate it
+	// +optional
+	Database DatabaseSpec `json:"database,omitempty"`
+
+	// IMConfig configures the job which integrates account-iam with IM
+	// +optional
+	IMConfig JobSpec `json:"imConfig,omitempty"`
+
+	// ManageIMIssuer decides whether the operator points OIDC_ISSUER_URL in
+	// the platform-auth-idp ConfigMap of IM at account-iam. Turning it off
+	// restores the issuer IM had before, and leaves it alone from then on.
+	// Defaults to true.
+	// +kubebuilder:default=true
+	// +optional
+	ManageIMIssuer *bool `json:"manageIMIssuer,omitempty"`
+
+	// CertRotation configures the iam-cert-rotation-manager deployment
+	// +optional
+	CertRotation CertRotationSpec `json:"certRotation,omitempty"`
+
+	// Hosts configures how the hosts of cp-console and account-iam are resolved
+	// +optional
+	Hosts HostsSpec `json:"hosts,omitempty"`
+
+	// Endpoints sets the public URLs of IM and account-iam, when they are not
+	// those of the hosts, such as behind a load balancer or on a custom domain
+	// +optional
+	Endpoints EndpointsSpec `json:"endpoints,omitempty"`
+}
+
+// EndpointsSpec defines the public URLs of IM and account-iam
+type EndpointsSpec struct {
+	// IMURL is the public URL of IM. When set, the cp-console host is not
+	// resolved. Defaults to the https URL of the cp-console host.
+	// +kubebuilder:validation:Pattern=`^https://`
+	// +optional
+	IMURL string `json:"imURL,omitempty"`
+
+	// AccountIAMURL is the public URL of account-iam. When set, the
+	// account-iam host is not resolved. Defaults to the https URL of the
+	// account-iam host.
+	// +kubebuilder:validation:Pattern=`^https://`
+	// +optional
+	AccountIAMURL string `json:"accountIAMURL,omitempty"`
+
+	// DiscoveryEndpoint is the OIDC discovery endpoint of IM. Defaults to
+	// /idprovider/v1/auth/.well-known/openid-configuration under the IM URL.
+	// +kubebuilder:validation:Pattern=`^https://`
+	// +optional
+	DiscoveryEndpoint string `json:"discoveryEndpoint,omitempty"`
+}
+
+// HostProvider is the kind of object the hosts of cp-console and account-iam are read from
+// +kubebuilder:validation:Enum=Route;HTTPRoute;Ingress;Spec
+type HostProvider string
+
+const (
+	// HostProviderRoute reads the hosts from the OpenShift Routes
+	HostProviderRoute HostProvider = "Route"
+	// HostProviderHTTPRoute reads the hosts from the Gateway API HTTPRoutes
+	HostProviderHTTPRoute HostProvider = "HTTPRoute"
+	// HostProviderIngress reads the hosts from the Ingresses
+	HostProviderIngress HostProvider = "Ingress"
+	// HostProviderSpec takes the hosts from the spec
+	HostProviderSpec HostProvider = "Spec"
+)
+
+// HostsSpec defines how the hosts of cp-console and account-iam are resolved
+type HostsSpec struct {
+	// Provider is the kind of object named cp-console and account-iam the
+	// hosts are read from. When empty, the first of Route, HTTPRoute and
+	// Ingress whose API is available and which has the object is used.
+	// +optional
+	Provider HostProvider `json:"provider,omitempty"`
+
+	// ConsoleHost is the host of cp-console when the provider is Spec
+	// +optional
+	ConsoleHost string `json:"consoleHost,omitempty"`
+
+	// AccountIAMHost is the host of account-iam when the provider is Spec
+	// +optional
+	AccountIAMHost string `json:"accountIAMHost,omitempty"`
+}
+
+// AccountIAMAppSpec defines the configuration of the account-iam application
+type AccountIAMAppSpec struct {
+	// Image is the account-iam application image. The DB migration job uses
+	// the same image unless database.migration.image is set.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Replicas is the number of account-iam pods. Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Resources are the compute resources of the account-iam container
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Realm is the IAM realm of account-iam. Defaults to PrimaryRealm.
+	// +optional
+	Realm string `json:"realm,omitempty"`
+
+	// ClientID is the OIDC client ID account-iam registers with IM. Defaults
+	// to mcsp-id. It is ignored when oidcClient.secretName is set.
+	// +optional
+	ClientID string `json:"clientID,omitempty"`
+
+	// OIDCClient configures the OIDC client account-iam authenticates with
+	// +optional
+	OIDCClient OIDCClientSpec `json:"oidcClient,omitempty"`
+}
+
+// OIDCClientSpec defines where the credentials of the OIDC client come from
+type OIDCClientSpec struct {
+	// SecretName is the name of a secret in the namespace of the AccountIAM
+	// with the client_id and client_secret keys of an OIDC client already
+	// registered with IM. When it is not set, the operator generates a client
+	// secret and registers the client with IM.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// DatabaseSpec defines the account-iam database and its bootstrap and migration jobs
+type DatabaseSpec struct {
+	// Cluster is the name of the EDB Cluster in the namespace which hosts the
+	// database. When empty, the Cluster common-service-db is used, or else the
+	// only Cluster of the namespace.
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+
+	// CASecret is a secret in the namespace with the CA certificate under the
+	// key ca.crt which account-iam verifies the EDB Cluster with. Defaults to
+	// the CA secret of the Cluster. It does not apply to an external
+	// database, which sets its own.
+	// +optional
+	CASecret string `json:"caSecret,omitempty"`
+
+	// Name is the name of the database. Defaults to account_iam.
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Schema is the schema of the account-iam tables. Defaults to accountiam.
+	// +optional
+	Schema string `json:"schema,omitempty"`
+
+	// User is the database user account-iam connects as. Defaults to user_accountiam.
+	// +optional
+	User string `json:"user,omitempty"`
+
+	// Bootstrap configures the job which creates the database and its user.
+	// It runs once for each database name.
+	// +optional
+	Bootstrap JobSpec `json:"bootstrap,omitempty"`
+
+	// Migration configures the job which migrates the database schema
+	// +optional
+	Migration MigrationSpec `json:"migration,omitempty"`
+
+	// PasswordRotation requests a rotation of the password of the database
+	// user. Setting it to a new value, for example a timestamp, rotates the
+	// password once. It does not apply to an external database.
+	// +optional
+	PasswordRotation string `json:"passwordRotation,omitempty"`
+
+	// DeletionPolicy decides what happens to the database and its user when
+	// the AccountIAM is deleted. Retain keeps them, Delete drops them.
+	// +kubebuilder:default=Retain
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// External connects account-iam to an existing PostgreSQL database
+	// instead of the EDB Cluster of Common Services. The database of name,
+	// with its schema, must exist and be owned by the user of the
+	// credentials. The bootstrap job, password rotation and deletion policy
+	// do not apply to it, the migration job does.
+	// +optional
+	External *ExternalDatabaseSpec `json:"external,omitempty"`
+
+	// Backup configures scheduled backups of the database, and restores of them
+	// +optional
+	Backup BackupSpec `json:"backup,omitempty"`
+}
+
+// BackupSpec configures the backups of the database with pg_dump, and the
+// restores of them with pg_restore. The image of the job defaults to the
+// image of the bootstrap job.
+// +kubebuilder:validation:XValidation:rule="!(has(self.pvc) && has(self.s3))",message="only one of pvc and s3 may be set"
+// +kubebuilder:validation:XValidation:rule="(!has(self.schedule) && !has(self.restore)) || has(self.pvc) || has(self.s3)",message="pvc or s3 must be set to back up to"
+type BackupSpec struct {
+	JobSpec `json:",inline"`
+
+	// Schedule is the cron schedule of the backups, for example "0 2 * * *".
+	// Backups are disabled when it is empty.
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
+
+	// PVC keeps the backups on a persistent volume claim
+	// +optional
+	PVC *BackupPVCSpec `json:"pvc,omitempty"`
+
+	// S3 uploads the backups to an S3 compatible object store
+	// +optional
+	S3 *BackupS3Spec `json:"s3,omitempty"`
+
+	// Restore requests a restore of the backup of this name, as reported in
+	// status.database.backup. account-iam is scaled down while the backup is
+	// restored, and the schema is migrated again after. Setting it to another
+	// name restores once more.
+	// +optional
+	Restore string `json:"restore,omitempty"`
+}
+
+// BackupPVCSpec defines the persistent volume claim the backups are kept on
+type BackupPVCSpec struct {
+	// ClaimName is the persistent volume claim in the namespace
+	// +kubebuilder:validation:MinLength=1
+	ClaimName string `json:"claimName"`
+
+	// Keep is the number of backups kept on the claim. Defaults to 7.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Keep *int32 `json:"keep,omitempty"`
+}
+
+// BackupS3Spec defines the S3 compatible bucket the backups are uploaded to.
+// The backups are expired by the lifecycle rules of the bucket.
+type BackupS3Spec struct {
+	// Endpoint is the URL of the object store, for example
+	// https://s3.us-east-1.amazonaws.com or http://minio.minio.svc:9000
+	// +kubebuilder:validation:Pattern=`^https?://`
+	Endpoint string `json:"endpoint"`
+
+	// Bucket is the bucket the backups are uploaded to
+	// +kubebuilder:validation:MinLength=1
+	Bucket string `json:"bucket"`
+
+	// Prefix is the prefix of the keys of the backups in the bucket
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// CredentialsSecret is the secret in the namespace with the
+	// AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY keys of the object store
+	// +kubebuilder:validation:MinLength=1
+	CredentialsSecret string `json:"credentialsSecret"`
+
+	// Image is the image of the MinIO client which transfers the backups
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// ExternalDatabaseSpec defines the connection to an existing PostgreSQL database
+type ExternalDatabaseSpec struct {
+	// Host is the host name of the PostgreSQL server
+	// +kubebuilder:validation:MinLength=1
+	Host string `json:"host"`
+
+	// Port is the port of the PostgreSQL server. Defaults to 5432.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+
+	// CredentialsSecret is the secret in the namespace with the username and
+	// password keys of the database user account-iam connects as
+	// +kubebuilder:validation:MinLength=1
+	CredentialsSecret string `json:"credentialsSecret"`
+
+	// SSLMode is the PostgreSQL sslmode of the connection. Defaults to
+	// verify-full with a CA secret, and to require without one.
+	// +kubebuilder:validation:Enum=disable;allow;prefer;require;verify-ca;verify-full
+	// +optional
+	SSLMode string `json:"sslMode,omitempty"`
+
+	// CASecret is the secret in the namespace with the CA certificate of the
+	// PostgreSQL server under the key ca.crt, which verify-ca and verify-full
+	// verify the server with
+	// +optional
+	CASecret string `json:"caSecret,omitempty"`
+}
+
+// DeletionPolicy is what happens to the account-iam database on deletion
+// +kubebuilder:validation:Enum=Retain;Delete
+type DeletionPolicy string
+
+const (
+	// DeletionPolicyRetain keeps the database and its user
+	DeletionPolicyRetain DeletionPolicy = "Retain"
+	// DeletionPolicyDelete drops the database and its user
+	DeletionPolicyDelete DeletionPolicy = "Delete"
+)
+
+// MigrationSpec defines the configuration of the DB migration job. The
+// migration runs again when its image or version changes.
+type MigrationSpec struct {
+	JobSpec `json:",inline"`
+
+	// Version identifies the migration to run. Changing it reruns the
+	// migration with the same image, for example after a failed run.
+	// +optional
+	Version string `json:"version,omitempty"`
+}
 
-	// Foo is an example field of AccountIAM. Edit accountiam_types.go to remove/update
-	Foo string `json:"foo,omitempty"`
+// JobSpec defines the configuration of an operand job
+type JobSpec struct {
+	// Image is the container image of the job
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Resources are the compute resources of the job container
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// CertRotationSpec defines the configuration of the iam-cert-rotation-manager
+type CertRotationSpec struct {
+	// Image is the iam-cert-rotation-manager image
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Replicas is the number of iam-cert-rotation-manager pods. Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Resources are the compute resources of the manager container
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
 // AccountIAMStatus defines the observed state of AccountIAM
 type AccountIAMStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// ObservedGeneration is the most recent generation observed by the operator
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Phase summarizes the state of the AccountIAM
+	// +optional
+	Phase Phase `json:"phase,omitempty"`
+
+	// Step is the reconcile step the operator is at. The next reconcile
+	// resumes from the first step which has not completed.
+	// +optional
+	Step ReconcileStep `json:"step,omitempty"`
+
+	// Conditions are the latest observations of the reconcile steps
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// Operands reports the readiness of each operand workload
+	// +optional
+	Operands []OperandStatus `json:"operands,omitempty"`
+
+	// Database reports the jobs which have completed against the database
+	// +optional
+	Database DatabaseStatus `json:"database,omitempty"`
+
+	// Endpoints reports the URLs of IM and account-iam in effect
+	// +optional
+	Endpoints EndpointsStatus `json:"endpoints,omitempty"`
+}
+
+// EndpointsStatus reports the URLs of IM and account-iam in effect, from the
+// spec or from the hosts
+type EndpointsStatus struct {
+	// IMURL is the URL of IM account-iam is configured with
+	// +optional
+	IMURL string `json:"imURL,omitempty"`
+
+	// AccountIAMURL is the URL of account-iam IM is configured with
+	// +optional
+	AccountIAMURL string `json:"accountIAMURL,omitempty"`
+
+	// DiscoveryEndpoint is the OIDC discovery endpoint account-iam is configured with
+	// +optional
+	DiscoveryEndpoint string `json:"discoveryEndpoint,omitempty"`
+}
+
+// DatabaseStatus reports the jobs which have completed against the database
+type DatabaseStatus struct {
+	// BootstrappedDatabase is the database which the bootstrap job has created
+	// +optional
+	BootstrappedDatabase string `json:"bootstrappedDatabase,omitempty"`
+
+	// BootstrapTime is when the bootstrap job was seen completed
+	// +optional
+	BootstrapTime *metav1.Time `json:"bootstrapTime,omitempty"`
+
+	// MigrationImage is the image of the last completed migration
+	// +optional
+	MigrationImage string `json:"migrationImage,omitempty"`
+
+	// MigrationVersion is the version of the last completed migration
+	// +optional
+	MigrationVersion string `json:"migrationVersion,omitempty"`
+
+	// MigrationTime is when the last migration was seen completed
+	// +optional
+	MigrationTime *metav1.Time `json:"migrationTime,omitempty"`
+
+	// PasswordRotation is the last completed rotation of the password of the database user
+	// +optional
+	PasswordRotation string `json:"passwordRotation,omitempty"`
+
+	// PasswordRotationTime is when the last rotation of the password completed
+	// +optional
+	PasswordRotationTime *metav1.Time `json:"passwordRotationTime,omitempty"`
+
+	// Cluster reports the state of the EDB Cluster which hosts the database
+	// +optional
+	Cluster DBClusterStatus `json:"cluster,omitempty"`
+
+	// SSLMode is the PostgreSQL sslmode account-iam connects to the database with
+	// +optional
+	SSLMode string `json:"sslMode,omitempty"`
+
+	// CASecret is the secret with the CA certificate account-iam verifies the
+	// database server with
+	// +optional
+	CASecret string `json:"caSecret,omitempty"`
+
+	// TLSEnforced is true when the connection to the database requires TLS
+	// and verifies the certificate of the server
+	// +optional
+	TLSEnforced bool `json:"tlsEnforced,omitempty"`
+
+	// Backup reports the last backup and restore of the database
+	// +optional
+	Backup BackupStatus `json:"backup,omitempty"`
 }
 
+// BackupStatus reports the last backup and restore of the database. A backup
+// is named after the job which took it.
+type BackupStatus struct {
+	// LastBackup is the name of the last backup
+	// +optional
+	LastBackup string `json:"lastBackup,omitempty"`
+
+	// LastBackupResult is the result of the last backup
+	// +optional
+	LastBackupResult BackupResult `json:"lastBackupResult,omitempty"`
+
+	// LastBackupTime is when the last backup finished, or started while it runs
+	// +optional
+	LastBackupTime *metav1.Time `json:"lastBackupTime,omitempty"`
+
+	// LastSuccessfulBackup is the name of the last backup which succeeded
+	// +optional
+	LastSuccessfulBackup string `json:"lastSuccessfulBackup,omitempty"`
+
+	// LastSuccessfulBackupTime is when the last backup which succeeded finished
+	// +optional
+	LastSuccessfulBackupTime *metav1.Time `json:"lastSuccessfulBackupTime,omitempty"`
+
+	// Restore is the last restore requested in the spec which has run
+	// +optional
+	Restore string `json:"restore,omitempty"`
+
+	// RestoreResult is the result of the last restore
+	// +optional
+	RestoreResult BackupResult `json:"restoreResult,omitempty"`
+
+	// RestoreTime is when the last restore finished
+	// +optional
+	RestoreTime *metav1.Time `json:"restoreTime,omitempty"`
+}
+
+// BackupResult is the result of a backup or a restore
+type BackupResult string
+
+const (
+	// BackupRunning means the job of the backup is running
+	BackupRunning BackupResult = "Running"
+	// BackupSucceeded means the job of the backup or restore has completed
+	BackupSucceeded BackupResult = "Succeeded"
+	// BackupFailed means the job of the backup or restore has failed
+	BackupFailed BackupResult = "Failed"
+)
+
+// DBClusterStatus reports the state of the EDB Cluster which hosts the database
+type DBClusterStatus struct {
+	// Name is the name of the EDB Cluster
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// Phase is the phase the EDB Cluster reports
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// CurrentPrimary is the instance of the EDB Cluster which is the primary
+	// +optional
+	CurrentPrimary string `json:"currentPrimary,omitempty"`
+
+	// Instances is the number of instances of the EDB Cluster
+	// +optional
+	Instances int64 `json:"instances,omitempty"`
+
+	// ReadyInstances is the number of ready instances of the EDB Cluster
+	// +optional
+	ReadyInstances int64 `json:"readyInstances,omitempty"`
+
+	// SuperuserSecret is the secret with the credentials of the superuser
+	// +optional
+	SuperuserSecret string `json:"superuserSecret,omitempty"`
+
+	// RWService is the service of the primary of the EDB Cluster
+	// +optional
+	RWService string `json:"rwService,omitempty"`
+
+	// CASecret is the secret with the CA certificate of the server
+	// certificate of the EDB Cluster
+	// +optional
+	CASecret string `json:"caSecret,omitempty"`
+}
+
+// OperandStatus reports the readiness of an operand workload
+type OperandStatus struct {
+	// Name is the name of the operand resource
+	Name string `json:"name"`
+
+	// Kind is the kind of the operand resource
+	Kind string `json:"kind"`
+
+	// Status is the readiness of the operand
+	Status OperandPhase `json:"status"`
+
+	// Message gives details when the operand is not ready
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// Phase is the summary phase of an AccountIAM
+// +kubebuilder:validation:Enum=Pending;Installing;Running;Failed
+type Phase string
+
+const (
+	// PhasePending means the prerequisites are not satisfied yet
+	PhasePending Phase = "Pending"
+	// PhaseInstalling means the operands are being deployed
+	PhaseInstalling Phase = "Installing"
+	// PhaseRunning means all the operands are ready
+	PhaseRunning Phase = "Running"
+	// PhaseFailed means a reconcile step or an operand failed
+	PhaseFailed Phase = "Failed"
+)
+
+// ReconcileStep is a step of the AccountIAM reconcile
+// +kubebuilder:validation:Enum=VerifyPrereqs;BootstrapDatabase;DeployOperands;IntegrateIM;Completed
+type ReconcileStep string
+
+const (
+	// StepVerifyPrereqs checks the prerequisite APIs and loads the bootstrap data
+	StepVerifyPrereqs ReconcileStep = "VerifyPrereqs"
+	// StepBootstrapDatabase creates and migrates the account-iam database
+	StepBootstrapDatabase ReconcileStep = "BootstrapDatabase"
+	// StepDeployOperands deploys the account-iam application and the cert rotation manager
+	StepDeployOperands ReconcileStep = "DeployOperands"
+	// StepIntegrateIM points IM at account-iam and runs the IM config job
+	StepIntegrateIM ReconcileStep = "IntegrateIM"
+	// StepCompleted means all the steps have completed
+	StepCompleted ReconcileStep = "Completed"
+)
+
+// OperandPhase is the readiness of an operand workload
+// +kubebuilder:validation:Enum=Ready;NotReady;Failed
+type OperandPhase string
+
+const (
+	// OperandReady means the operand is available, or has completed for jobs
+	OperandReady OperandPhase = "Ready"
+	// OperandNotReady means the operand is missing or still progressing
+	OperandNotReady OperandPhase = "NotReady"
+	// OperandFailed means the operand has failed
+	OperandFailed OperandPhase = "Failed"
+)
+
+const (
+	// ConditionPrereqsSatisfied reports whether the prerequisites of account-iam are present
+	ConditionPrereqsSatisfied = "PrereqsSatisfied"
+	// ConditionDatabaseReady reports whether the database is bootstrapped and migrated
+	ConditionDatabaseReady = "DatabaseReady"
+	// ConditionOperandReady reports whether the account-iam workloads are ready
+	ConditionOperandReady = "OperandReady"
+	// ConditionIMIntegrated reports whether account-iam is integrated with IM
+	ConditionIMIntegrated = "IMIntegrated"
+	// ConditionCredentialsRotated reports whether the requested rotation of
+	// the database password has completed. It is not part of Ready.
+	ConditionCredentialsRotated = "CredentialsRotated"
+	// ConditionDatabaseRestored reports whether the requested restore of a
+	// backup of the database has completed. It is not part of Ready.
+	ConditionDatabaseRestored = "DatabaseRestored"
+	// ConditionReady reports whether all the other conditions are satisfied
+	ConditionReady = "Ready"
+)
+
+const (
+	// ReasonSucceeded is used when a step has completed
+	ReasonSucceeded = "Succeeded"
+	// ReasonInProgress is used when a step is waiting on its operands
+	ReasonInProgress = "InProgress"
+	// ReasonFailed is used when a step has returned an error
+	ReasonFailed = "Failed"
+	// ReasonPrerequisitesMissing is used when the APIs of prerequisite operators are not installed
+	ReasonPrerequisitesMissing = "PrerequisitesMissing"
+	// ReasonOperandFailed is used when an operand has failed
+	ReasonOperandFailed = "OperandFailed"
+	// ReasonDBClusterNotReady is used when the EDB Cluster which hosts the database is not ready
+	ReasonDBClusterNotReady = "DBClusterNotReady"
+	// ReasonInvalidDBCredentials is used when the secrets of an external database are missing or incomplete
+	ReasonInvalidDBCredentials = "InvalidDBCredentials"
+	// ReasonInvalidOIDCClient is used when the OIDC client secret is missing or incomplete
+	ReasonInvalidOIDCClient = "InvalidOIDCClient"
+)
+
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+//+kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+//+kubebuilder:printcolumn:name="Step",type="string",JSONPath=".status.step",priority=1
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // AccountIAM is the Schema for the accountiams API
 type AccountIAM struct {
@@ -20,54 +20,84 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
 	"errors"
 	"fmt"
-	"os"
+	"maps"
 	"reflect"
-	"text/template"
+	"strings"
+	"sync"
 	"time"
 
-	ocproute "github.com/openshift/api/route/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 
 	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
 	"github.com/IBM/ibm-user-management-operator/internal/resources"
 	res "github.com/IBM/ibm-user-management-operator/internal/resources/yamls"
 	"github.com/ghodss/yaml"
-	olmapi "github.com/operator-framework/api/pkg/operators/v1"
 )
 
+// requeueInterval is how long to wait before checking a step in progress again
+const requeueInterval = 20 * time.Second
+
 // AccountIAMReconciler reconciles a AccountIAM object
 type AccountIAMReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
-	Config *rest.Config
+	Scheme   *runtime.Scheme
+	Config   *rest.Config
+	Recorder record.EventRecorder
+	// MaxConcurrentReconciles is the number of AccountIAMs reconciled at the same time
+	MaxConcurrentReconciles int
+	// Redactor keeps the secret values out of the logs and events
+	Redactor *Redactor
+
+	controller controller.Controller
+	cache      cache.Cache
+	restMapper meta.RESTMapper
+	// watches holds the GVKs of the optional kinds being watched
+	watches sync.Map
+	// discoveryClient caches the discovery of the APIs, it is invalidated
+	// when the CRDs of the optional APIs change
+	discoveryClient discovery.CachedDiscoveryInterface
+	discoveryLock   sync.Mutex
 }
 
+// BootstrapSecret holds the values of the user-mgmt-bootstrap secret. The
+// fields tagged secret are kept out of the logs and events.
 type BootstrapSecret struct {
 	Realm               string
 	ClientID            string
-	ClientSecret        string
+	ClientSecret        string `secret:"true"`
 	DiscoveryEndpoint   string
-	PGPassword          string
+	PGPassword          string `secret:"true"`
+	PGPasswordPending   string `secret:"true"`
 	DefaultAUDValue     string
 	DefaultIDPValue     string
 	DefaultRealmValue   string
-	SREMCSPGroupsToken  string
+	SREMCSPGroupsToken  string `secret:"true"`
 	GlobalRealmValue    string
 	GlobalAccountIDP    string
 	GlobalAccountAud    string
@@ -77,24 +107,37 @@ type BootstrapSecret struct {
 	AccountIAMNamespace string
 }
 
-var BootstrapData BootstrapSecret
+// reconcileStep is a step of the reconcile, which reports whether it has
+// completed. The steps share the bootstrap data of the instance, which is
+// loaded by the first step of each reconcile.
+type reconcileStep struct {
+	name      operatorv1alpha1.ReconcileStep
+	condition string
+	reconcile func(ctx context.Context, instance *operatorv1alpha1.AccountIAM, bootstrapData *BootstrapSecret) (bool, error)
+}
 
 //+kubebuilder:rbac:groups=operator.ibm.com,resources=accountiams,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=operator.ibm.com,resources=accountiams/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=operator.ibm.com,resources=accountiams/finalizers,verbs=update
 //+kubebuilder:rbac:groups=operators.coreos.com,resources=operatorgroups,verbs=get;list;watch
 //+kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch
 //+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=batch,resources=jobs;cronjobs,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=postgresql.k8s.enterprisedb.io,resources=clusters,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch
 //+kubebuilder:rbac:groups=liberty.websphere.ibm.com,resources=webspherelibertyapplications,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings;roles,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=security.openshift.io,resources=securitycontextconstraints,verbs=use
 //+kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -105,16 +148,22 @@ var BootstrapData BootstrapSecret
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.16.3/pkg/reconcile
-func (r *AccountIAMReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *AccountIAMReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 
 	klog.Infof("Reconciling AccountIAM using fid image")
 
+	// controller-runtime logs the returned error with its own logger, which
+	// the klog filter does not cover
+	defer func() {
+		err = r.Redactor.RedactError(err)
+	}()
+
 	instance := &operatorv1alpha1.AccountIAM{}
-	err := r.Client.Get(context.TODO(), req.NamespacedName, instance)
+	err = r.Client.Get(context.TODO(), req.NamespacedName, instance)
 	if err != nil {
 		if k8serrors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
-			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
+			// Owned objects are automatically garbage collected, the rest is cleaned up by the finalizer.
 			// Return and don't requeue
 			klog.Infof("CR instance not found, don't requeue")
 			return ctrl.Result{}, nil
@@ -123,145 +172,210 @@ func (r *AccountIAMReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	if err := r.verifyPrereq(ctx, instance); err != nil {
-		return ctrl.Result{}, err
+	// Tear down what the owner references do not cover before letting the instance go
+	if instance.DeletionTimestamp != nil {
+		if !controllerutil.ContainsFinalizer(instance, resources.Finalizer) {
+			return ctrl.Result{}, nil
+		}
+		done, err := r.finalize(ctx, instance)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if !done {
+			klog.Infof("Finalizing AccountIAM %s/%s is in progress, requeue after %v", instance.Namespace, instance.Name, requeueInterval)
+			return ctrl.Result{RequeueAfter: requeueInterval}, nil
+		}
+		r.recordEvent(instance, corev1.EventTypeNormal, "Finalized", "Finished tearing down AccountIAM %s", instance.Name)
+		controllerutil.RemoveFinalizer(instance, resources.Finalizer)
+		return ctrl.Result{}, r.Update(ctx, instance)
 	}
 
-	if err := r.reconcileOperandResources(ctx, instance); err != nil {
-		return ctrl.Result{}, err
+	if controllerutil.AddFinalizer(instance, resources.Finalizer) {
+		if err := r.Update(ctx, instance); err != nil {
+			return ctrl.Result{}, err
+		}
 	}
 
-	// create im integration job
-	if err := r.configIM(ctx, instance); err != nil {
-		return ctrl.Result{}, err
+	// Record the outcome of the steps below in the status, whether they succeed or not
+	original := instance.DeepCopy()
+	defer func() {
+		if statusErr := r.updateStatus(ctx, instance, original); statusErr != nil && err == nil {
+			err = statusErr
+		}
+	}()
+
+	// Each step applies its resources and reports whether they have
+	// completed. Instead of blocking on a step in progress, requeue and
+	// resume from it on the next reconcile.
+	steps := []reconcileStep{
+		{operatorv1alpha1.StepVerifyPrereqs, operatorv1alpha1.ConditionPrereqsSatisfied, r.verifyPrereq},
+		{operatorv1alpha1.StepBootstrapDatabase, operatorv1alpha1.ConditionDatabaseReady, r.reconcileDatabase},
+		{operatorv1alpha1.StepDeployOperands, operatorv1alpha1.ConditionOperandReady, r.reconcileOperandResources},
+		{operatorv1alpha1.StepIntegrateIM, operatorv1alpha1.ConditionIMIntegrated, r.configIM},
+	}
+	bootstrapData := &BootstrapSecret{}
+	for _, step := range steps {
+		instance.Status.Step = step.name
+		done, err := step.reconcile(ctx, instance, bootstrapData)
+		if err != nil {
+			r.markFailed(instance, step.condition, err)
+			return ctrl.Result{}, err
+		}
+		if !done {
+			klog.Infof("Step %s of AccountIAM %s/%s is in progress, requeue after %v", step.name, instance.Namespace, instance.Name, requeueInterval)
+			return ctrl.Result{RequeueAfter: requeueInterval}, nil
+		}
 	}
+	instance.Status.Step = operatorv1alpha1.StepCompleted
 
 	return ctrl.Result{}, nil
 }
 
-func (r *AccountIAMReconciler) verifyPrereq(ctx context.Context, instance *operatorv1alpha1.AccountIAM) error {
-	og := &olmapi.OperatorGroupList{}
-	err := r.Client.List(ctx, og, &client.ListOptions{
-		Namespace: os.Getenv("WATCH_NAMESPACE"),
-	})
-	if err != nil {
-		return err
+// verifyPrereq checks the prerequisites of account-iam and loads the
+// bootstrap data of the instance
+func (r *AccountIAMReconciler) verifyPrereq(ctx context.Context, instance *operatorv1alpha1.AccountIAM, bootstrapData *BootstrapSecret) (bool, error) {
+	if ready, err := r.checkPrerequisites(instance); err != nil || !ready {
+		return false, err
 	}
-
-	existEDB, err := r.CheckCRD(resources.EDBAPIGroupVersion, resources.EDBClusterKind)
-	if err != nil {
-		return err
+	if err := r.watchOptional(schema.FromAPIVersionAndKind(resources.WebSphereAPIGroupVersion, resources.WebSphereKind), r.ownedByAccountIAM()); err != nil {
+		return false, err
 	}
-	if !existEDB {
-		return errors.New("missing EDB prereq")
+
+	// watch the objects the hosts may be read from, whose APIs are optional
+	for _, apiVersionKind := range [][2]string{
+		{resources.RouteAPIGroupVersion, resources.RouteKind},
+		{resources.HTTPRouteAPIGroupVersion, resources.HTTPRouteKind},
+	} {
+		exist, err := r.CheckCRD(apiVersionKind[0], apiVersionKind[1])
+		if err != nil {
+			return false, err
+		}
+		if !exist {
+			continue
+		}
+		if err := r.watchOptional(schema.FromAPIVersionAndKind(apiVersionKind[0], apiVersionKind[1]),
+			handler.EnqueueRequestsFromMapFunc(r.accountIAMsInNamespace), withName("cp-console", "account-iam")); err != nil {
+			return false, err
+		}
 	}
 
-	existWebsphere, err := r.CheckCRD(resources.WebSphereAPIGroupVersion, resources.WebSphereKind)
+	imURL, err := r.endpointURL(ctx, instance, instance.Spec.Endpoints.IMURL, "cp-console")
 	if err != nil {
-		return err
-	}
-	if !existWebsphere {
-		return errors.New("missing Websphere Liberty prereq")
+		return false, err
 	}
 
-	// Generate PG password
-	pgPassword, err := generatePassword()
-
-	if err != nil {
-		return err
+	if err := r.loadBootstrapData(ctx, instance, imURL, bootstrapData); err != nil {
+		return false, err
+	}
+	if ready, err := r.loadOIDCClient(ctx, instance, bootstrapData); err != nil || !ready {
+		return false, err
 	}
-	klog.Infof("Generated PG password: %s", pgPassword)
 
-	// Get cp-console route
-	host, err := r.getHost(ctx, "cp-console", instance.Namespace)
+	decodedData, err := r.decodeData(*bootstrapData)
 	if err != nil {
-		return err
+		return false, err
 	}
-	klog.Infof("cp-console route host: %s", host)
+	instance.Status.Endpoints.IMURL = decodedData.IAMHOSTURL
+	instance.Status.Endpoints.DiscoveryEndpoint = decodedData.DiscoveryEndpoint
+
+	setCondition(instance, operatorv1alpha1.ConditionPrereqsSatisfied, metav1.ConditionTrue, operatorv1alpha1.ReasonSucceeded, "")
+	return true, nil
+}
 
-	// Create bootstrap secret
-	bootstrapsecret, err := r.initBootstrapData(ctx, instance.Namespace, pgPassword, host)
+// loadBootstrapData reads the bootstrap secret, creating it when it does not
+// exist yet, into the bootstrap data of this reconcile. Its secret values are
+// kept out of the logs from then on.
+func (r *AccountIAMReconciler) loadBootstrapData(ctx context.Context, instance *operatorv1alpha1.AccountIAM, imURL string, bootstrapData *BootstrapSecret) error {
+	bootstrapsecret, err := r.initBootstrapData(ctx, instance, imURL)
 	if err != nil {
 		return err
 	}
 
-	// Read the values from bootstrap secret and store in BootstrapData struct
+	// Read the values from bootstrap secret and store in the bootstrap data of this reconcile
 	bootstrapConverter, err := yaml.Marshal(bootstrapsecret.Data)
 	if err != nil {
 		return err
 	}
-	if err := yaml.Unmarshal(bootstrapConverter, &BootstrapData); err != nil {
+	if err := yaml.Unmarshal(bootstrapConverter, bootstrapData); err != nil {
 		return err
 	}
 
-	if err := r.cleanJob(ctx, instance.Namespace); err != nil {
+	decodedData, err := r.decodeData(*bootstrapData)
+	if err != nil {
 		return err
 	}
-
+	r.Redactor.AddSecrets(secretValues(*bootstrapData)...)
+	r.Redactor.AddSecrets(secretValues(decodedData)...)
 	return nil
 }
 
-// Initialize BootstrapData with default values
-func (r *AccountIAMReconciler) initBootstrapData(ctx context.Context, ns string, pg []byte, host string) (*corev1.Secret, error) {
+// initBootstrapData creates or updates the bootstrap secret. The values
+// derived from the spec and the IM URL are recomputed, the generated
+// passwords and the keys the operator does not know of are kept, and the
+// values of the overrides secret take precedence over both. The values set
+// by the user in a bootstrap secret from before the overrides secret are
+// moved into it first.
+func (r *AccountIAMReconciler) initBootstrapData(ctx context.Context, instance *operatorv1alpha1.AccountIAM, imURL string) (*corev1.Secret, error) {
 
+	ns := instance.Namespace
 	bootstrapsecret := &corev1.Secret{}
+	exists := true
 	if err := r.Get(ctx, client.ObjectKey{Name: "user-mgmt-bootstrap", Namespace: ns}, bootstrapsecret); err != nil {
-		if k8serrors.IsNotFound(err) {
-
-			klog.Info("Creating bootstrap secret with PG password")
-			newsecret := &corev1.Secret{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      "user-mgmt-bootstrap",
-					Namespace: ns,
-				},
-				Data: map[string][]byte{
-					"Realm":               []byte("PrimaryRealm"),
-					"ClientID":            []byte("mcsp-id"),
-					"ClientSecret":        []byte("mcsp-secret"),
-					"DiscoveryEndpoint":   []byte("https://" + host + "/idprovider/v1/auth/.well-known/openid-configuration"),
-					"UserValidationAPIV2": []byte("https://openshift.default.svc/apis/user.openshift.io/v1/users/~"),
-					"DefaultAUDValue":     []byte("mcsp-id"),
-					"DefaultIDPValue":     []byte("https://" + host + "/idprovider/v1/auth"),
-					"DefaultRealmValue":   []byte("PrimaryRealm"),
-					"SREMCSPGroupsToken":  []byte("mcsp-im-integration-admin"),
-					"GlobalRealmValue":    []byte("PrimaryRealm"),
-					"GlobalAccountIDP":    []byte("https://" + host + "/idprovider/v1/auth"),
-					"GlobalAccountAud":    []byte("mcsp-id"),
-					"AccountIAMNamespace": []byte(ns),
-					"PGPassword":          pg,
-					"IAMHOSTURL":          []byte("https://" + host),
-				},
-				Type: corev1.SecretTypeOpaque,
-			}
+		if !k8serrors.IsNotFound(err) {
+			return nil, err
+		}
+		exists = false
+		bootstrapsecret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "user-mgmt-bootstrap",
+				Namespace: ns,
+			},
+			Type: corev1.SecretTypeOpaque,
+		}
+	} else if err := r.replaceLegacyClientSecret(ctx, instance, bootstrapsecret); err != nil {
+		return nil, err
+	}
 
-			if err := r.Create(ctx, newsecret); err != nil {
-				if !k8serrors.IsAlreadyExists(err) {
-					return nil, err
-				}
-			}
-			return newsecret, nil
-		} else {
+	derived := derivedBootstrapData(instance, imURL)
+	if exists {
+		if err := r.migrateBootstrapOverrides(ctx, instance, bootstrapsecret, derived); err != nil {
 			return nil, err
 		}
 	}
-	return bootstrapsecret, nil
-}
 
-// Get the host of the route
-func (r *AccountIAMReconciler) getHost(ctx context.Context, name string, ns string) (string, error) {
-	// config := &corev1.ConfigMap{}
-	// if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: ns}, config); err != nil {
-	// 	klog.Errorf("Failed to get route %s in namespace %s", name, ns)
-	// 	return "", err
-	// }
-	// return config.Data["cluster_endpoint"], nil
+	data := maps.Clone(derived)
+	if err := keepGeneratedBootstrapData(bootstrapsecret.Data, data); err != nil {
+		return nil, err
+	}
+	if err := r.applyBootstrapOverrides(ctx, ns, data); err != nil {
+		return nil, err
+	}
+	keepUnknownBootstrapData(bootstrapsecret.Data, derived, data)
+
+	if !exists {
+		klog.Info("Creating bootstrap secret with PG password")
+		bootstrapsecret.Annotations = map[string]string{resources.BootstrapOverridesMigratedAnnotation: "true"}
+		bootstrapsecret.Data = data
+		if err := r.Create(ctx, bootstrapsecret); err != nil {
+			if !k8serrors.IsAlreadyExists(err) {
+				return nil, err
+			}
+		}
+		return bootstrapsecret, nil
+	}
 
-	sourceRoute := &ocproute.Route{}
-	if err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: ns}, sourceRoute); err != nil {
-		klog.Errorf("Failed to get route %s in namespace %s", name, ns)
-		return "", err
+	changed := changedKeys(bootstrapsecret.Data, data)
+	if len(changed) == 0 {
+		return bootstrapsecret, nil
+	}
+	klog.Infof("Updating %s in secret user-mgmt-bootstrap in namespace %s", strings.Join(changed, ", "), ns)
+	bootstrapsecret.Data = data
+	if err := r.Update(ctx, bootstrapsecret); err != nil {
+		klog.Errorf("Failed to update secret user-mgmt-bootstrap in namespace %s: %v", ns, err)
+		return nil, err
 	}
-	return sourceRoute.Spec.Host, nil
+	r.recordEvent(instance, corev1.EventTypeNormal, "BootstrapDataUpdated", "Updated %s in secret user-mgmt-bootstrap", strings.Join(changed, ", "))
+	return bootstrapsecret, nil
 }
 
 func generatePassword() ([]byte, error) {
@@ -276,69 +390,134 @@ func generatePassword() ([]byte, error) {
 	return result, nil
 }
 
-func (r *AccountIAMReconciler) cleanJob(ctx context.Context, ns string) error {
-	object := &unstructured.Unstructured{}
-	manifest := []byte(res.DB_MIGRATION_MCSPID)
-	if err := yaml.Unmarshal(manifest, object); err != nil {
-		return err
+// reconcileDatabase creates the account-iam database once the EDB Cluster is
+// ready and migrates its schema. It has completed once both jobs have
+// completed. An external database already exists, so only its schema is
+// migrated, with the credentials of its secret.
+func (r *AccountIAMReconciler) reconcileDatabase(ctx context.Context, instance *operatorv1alpha1.AccountIAM, bootstrapData *BootstrapSecret) (bool, error) {
+	external := instance.Spec.Database.External != nil
+
+	// The jobs connect to the EDB Cluster as its superuser
+	if external {
+		instance.Status.Database.Cluster = operatorv1alpha1.DBClusterStatus{}
+	} else if ready, err := r.checkDBCluster(ctx, instance); err != nil || !ready {
+		return false, err
 	}
-	object.SetNamespace(ns)
-	klog.Infof("Cleaning up job %s", object.GetName())
-	background := metav1.DeletePropagationBackground
-	if err := r.Delete(ctx, object, &client.DeleteOptions{
-		PropagationPolicy: &background,
-	}); err != nil {
-		if !k8serrors.IsNotFound(err) {
-			return err
+
+	operandConfig, err := newOperandConfig(instance)
+	if err != nil {
+		return false, err
+	}
+	if external {
+		if ready, err := r.loadExternalDBCredentials(ctx, instance, bootstrapData, &operandConfig); err != nil || !ready {
+			return false, err
 		}
 	}
 
-	object = &unstructured.Unstructured{}
-	manifest = []byte(res.DB_BOOTSTRAP_JOB)
-	if err := yaml.Unmarshal(manifest, object); err != nil {
-		return err
+	decodedData, err := r.decodeData(*bootstrapData)
+	if err != nil {
+		return false, err
 	}
-	object.SetNamespace(ns)
-	if err := r.Delete(ctx, object, &client.DeleteOptions{
-		PropagationPolicy: &background,
-	}); err != nil {
-		if !k8serrors.IsNotFound(err) {
-			return err
+
+	dbStatus := &instance.Status.Database
+	dbStatus.SSLMode = operandConfig.DBSSLMode
+	dbStatus.CASecret = operandConfig.DBCASecret
+	dbStatus.TLSEnforced = dbTLSEnforced(operandConfig)
+
+	// The bootstrap job runs once for each database
+	bootstrapJob := operatorv1alpha1.OperandStatus{Name: "create-account-iam-db", Kind: "Job", Status: operatorv1alpha1.OperandReady}
+	if !external && dbStatus.BootstrappedDatabase != operandConfig.DBName {
+		klog.Infof("Creating DB Bootstrap Job")
+		bootstrapJob, err = r.reconcileJob(ctx, instance, res.DB_BOOTSTRAP_JOB, TemplateData{decodedData, operandConfig}, operandConfig.DBName)
+		if err != nil {
+			return false, err
+		}
+		if bootstrapJob.Status == operatorv1alpha1.OperandReady {
+			now := metav1.Now()
+			dbStatus.BootstrappedDatabase = operandConfig.DBName
+			dbStatus.BootstrapTime = &now
+			// a new database needs the schema migrated again
+			dbStatus.MigrationImage = ""
+			dbStatus.MigrationVersion = ""
+			dbStatus.MigrationTime = nil
 		}
 	}
 
-	return nil
-}
+	// Rotate the password before it is rendered into the database secret
+	if !external && bootstrapJob.Status == operatorv1alpha1.OperandReady {
+		if err := r.rotateDBPassword(ctx, instance, bootstrapData, operandConfig); err != nil {
+			return false, err
+		}
+	}
 
-func (r *AccountIAMReconciler) reconcileOperandResources(ctx context.Context, instance *operatorv1alpha1.AccountIAM) error {
+	// The migration job reads the database secret
+	klog.Infof("Creating MCSP secrets")
+	if err := r.InjectData(ctx, instance, res.APP_SECRETS, TemplateData{*bootstrapData, operandConfig}); err != nil {
+		return false, err
+	}
 
-	// TODO: will need to find a better place to initialize the database
-	klog.Infof("Creating DB Bootstrap Job")
-	object := &unstructured.Unstructured{}
-	manifest := []byte(res.DB_BOOTSTRAP_JOB)
-	if err := yaml.Unmarshal(manifest, object); err != nil {
-		return err
+	// A backup is restored into the database before its schema is migrated
+	if bootstrapJob.Status == operatorv1alpha1.OperandReady {
+		if restored, err := r.restoreDatabase(ctx, instance, TemplateData{decodedData, operandConfig}); err != nil || !restored {
+			return false, err
+		}
 	}
-	object.SetNamespace(instance.Namespace)
-	if err := controllerutil.SetControllerReference(instance, object, r.Scheme); err != nil {
-		return err
+
+	// The migration job runs again when its image or version changes
+	migrationJob := operatorv1alpha1.OperandStatus{Name: "account-iam-db-migration-mcspid", Kind: "Job", Status: operatorv1alpha1.OperandReady}
+	switch {
+	case bootstrapJob.Status != operatorv1alpha1.OperandReady:
+		migrationJob.Status = operatorv1alpha1.OperandNotReady
+		migrationJob.Message = "Waiting for the database to be created"
+	case dbStatus.MigrationImage != operandConfig.DBMigrationImage || dbStatus.MigrationVersion != operandConfig.DBMigrationVersion:
+		klog.Infof("Creating DB Migration Job")
+		if err := r.InjectData(ctx, instance, []string{res.DB_MIGRATION_MCSPID_SA}, TemplateData{decodedData, operandConfig}); err != nil {
+			return false, err
+		}
+		run := operandConfig.DBMigrationImage + "," + operandConfig.DBMigrationVersion
+		migrationJob, err = r.reconcileJob(ctx, instance, res.DB_MIGRATION_MCSPID, TemplateData{decodedData, operandConfig}, run)
+		if err != nil {
+			return false, err
+		}
+		if migrationJob.Status == operatorv1alpha1.OperandReady {
+			now := metav1.Now()
+			dbStatus.MigrationImage = operandConfig.DBMigrationImage
+			dbStatus.MigrationVersion = operandConfig.DBMigrationVersion
+			dbStatus.MigrationTime = &now
+		}
 	}
-	if err := r.createOrUpdate(ctx, object); err != nil {
-		return err
+	operands := []operatorv1alpha1.OperandStatus{migrationJob}
+	if !external {
+		operands = append([]operatorv1alpha1.OperandStatus{bootstrapJob}, operands...)
+	}
+	setOperandsCondition(instance, operatorv1alpha1.ConditionDatabaseReady, operands...)
+	if !meta.IsStatusConditionTrue(instance.Status.Conditions, operatorv1alpha1.ConditionDatabaseReady) {
+		return false, nil
 	}
 
-	// Manifests which need data injected before creation
-	klog.Infof("Creating MCSP secrets")
-	if err := r.InjectData(ctx, instance, res.APP_SECRETS, BootstrapData); err != nil {
-		return err
+	// The database is backed up once it is migrated
+	if err := r.reconcileBackup(ctx, instance, TemplateData{decodedData, operandConfig}); err != nil {
+		return false, err
 	}
+	return true, nil
+}
 
-	klog.Infof("Creating MCSP ConfigMaps")
-	decodedData, err := r.decodeData(BootstrapData)
+// reconcileOperandResources deploys the account-iam application and the
+// cert rotation manager. It has completed once both are ready.
+func (r *AccountIAMReconciler) reconcileOperandResources(ctx context.Context, instance *operatorv1alpha1.AccountIAM, bootstrapData *BootstrapSecret) (bool, error) {
+
+	operandConfig, err := newOperandConfig(instance)
 	if err != nil {
-		return err
+		return false, err
 	}
 
+	decodedData, err := r.decodeData(*bootstrapData)
+	if err != nil {
+		return false, err
+	}
+
+	klog.Infof("Creating MCSP ConfigMaps")
+
 	//print decodedData
 	// reflectValue := reflect.ValueOf(decodedData)
 	// reflectType := reflect.TypeOf(decodedData)
@@ -349,119 +528,202 @@ func (r *AccountIAMReconciler) reconcileOperandResources(ctx context.Context, in
 	// 	klog.Infof("Field Name: %s, Field Value: %s", fieldName, fieldValue)
 	// }
 
-	if err := r.InjectData(ctx, instance, res.APP_CONFIGS, decodedData); err != nil {
-		return err
+	if err := r.InjectData(ctx, instance, res.APP_CONFIGS, TemplateData{decodedData, operandConfig}); err != nil {
+		return false, err
 	}
 
 	// static manifests which do not change
 	klog.Infof("Creating MCSP static yamls")
-	staticYamls := append(res.APP_STATIC_YAMLS, res.CertRotationYamls...)
-	for _, v := range staticYamls {
+	for _, v := range res.APP_STATIC_YAMLS {
 		object := &unstructured.Unstructured{}
 		manifest := []byte(v)
 		if err := yaml.Unmarshal(manifest, object); err != nil {
-			return err
+			return false, err
 		}
 		object.SetNamespace(instance.Namespace)
 		if err := controllerutil.SetControllerReference(instance, object, r.Scheme); err != nil {
-			return err
+			return false, err
 		}
-		if err := r.createOrUpdate(ctx, object); err != nil {
-			return err
+		if err := r.apply(ctx, instance, object); err != nil {
+			return false, err
 		}
 	}
 
+	klog.Infof("Creating MCSP workloads")
+	workloads := append(res.APP_WORKLOADS, res.CertRotationYamls...)
+	if err := r.InjectData(ctx, instance, workloads, TemplateData{decodedData, operandConfig}); err != nil {
+		return false, err
+	}
+
+	app, err := r.libertyAppStatus(ctx, instance.Namespace, "account-iam")
+	if err != nil {
+		return false, err
+	}
+	certRotation, err := r.deploymentStatus(ctx, instance.Namespace, "iam-cert-rotation-manager")
+	if err != nil {
+		return false, err
+	}
+	setOperandsCondition(instance, operatorv1alpha1.ConditionOperandReady, app, certRotation)
+
+	return meta.IsStatusConditionTrue(instance.Status.Conditions, operatorv1alpha1.ConditionOperandReady), nil
+}
+
+// updateIssuer points the IM issuer at account-iam and rolls the IM
+// deployments out to pick it up. It has completed once the rollouts have
+// replaced every replica. When the issuer is not managed, the original one
+// is restored instead.
+func (r *AccountIAMReconciler) updateIssuer(ctx context.Context, instance *operatorv1alpha1.AccountIAM, bootstrapData *BootstrapSecret) (bool, error) {
+	if instance.Spec.ManageIMIssuer != nil && !*instance.Spec.ManageIMIssuer {
+		return true, r.restoreIssuer(ctx, instance)
+	}
+
+	decodedData, err := r.decodeData(*bootstrapData)
+	if err != nil {
+		return false, err
+	}
+
 	// Temporary update issuer in platform-auth-idp configmap
 	klog.Infof("Updating platform-auth-idp configmap")
 	idpconfig := &corev1.ConfigMap{}
 	if err := r.Get(ctx, client.ObjectKey{Name: "platform-auth-idp", Namespace: instance.Namespace}, idpconfig); err != nil {
 		klog.Errorf("Failed to get configmap platform-auth-idp in namespace %s", instance.Namespace)
-		return err
+		return false, err
 	}
 	currentIssuer := idpconfig.Data["OIDC_ISSUER_URL"]
 	idpValue := decodedData.DefaultIDPValue
 
 	if currentIssuer == idpValue {
 		klog.Infof("ConfigMap platform-auth-idp already has the desired value for OIDC_ISSUER_URL: %s", currentIssuer)
-		return nil // Skip the update as the value is already set
-	}
-
-	idpconfig.Data["OIDC_ISSUER_URL"] = decodedData.DefaultIDPValue
-	if err := r.Update(ctx, idpconfig); err != nil {
-		klog.Errorf("Failed to update ConfigMap platform-auth-idp in namespace %s: %v", instance.Namespace, err)
-		return err
+	} else {
+		// Record the original issuer, so that it is restored when the AccountIAM is deleted
+		if _, ok := idpconfig.Annotations[resources.OriginalIssuerAnnotation]; !ok {
+			if idpconfig.Annotations == nil {
+				idpconfig.Annotations = map[string]string{}
+			}
+			idpconfig.Annotations[resources.OriginalIssuerAnnotation] = currentIssuer
+		}
+		idpconfig.Data["OIDC_ISSUER_URL"] = decodedData.DefaultIDPValue
+		if err := r.Update(ctx, idpconfig); err != nil {
+			klog.Errorf("Failed to update ConfigMap platform-auth-idp in namespace %s: %v", instance.Namespace, err)
+			return false, err
+		}
 	}
 
-	// Delete the platform-auth-service and platform-identity-provider pod to restart it
-	if err := r.restartAndCheckPod(ctx, instance.Namespace, "platform-auth-service"); err != nil {
-		return err
+	// Roll out the platform-auth-service and platform-identity-provider deployments to restart them
+	var waiting []string
+	for _, name := range imDeployments {
+		if err := r.rolloutIssuer(ctx, instance.Namespace, name, idpValue); err != nil {
+			return false, err
+		}
+		rollout, err := r.rolloutStatus(ctx, instance.Namespace, name)
+		if err != nil {
+			return false, err
+		}
+		setOperandStatus(instance, rollout)
+		switch rollout.Status {
+		case operatorv1alpha1.OperandFailed:
+			message := fmt.Sprintf("Rollout of deployment %s failed: %s", name, rollout.Message)
+			setCondition(instance, operatorv1alpha1.ConditionIMIntegrated, metav1.ConditionFalse, operatorv1alpha1.ReasonOperandFailed, message)
+			return false, nil
+		case operatorv1alpha1.OperandNotReady:
+			waiting = append(waiting, fmt.Sprintf("deployment %s: %s", name, rollout.Message))
+		}
 	}
-	klog.Infof(" platform-auth-service pod is ready")
-
-	if err := r.restartAndCheckPod(ctx, instance.Namespace, "platform-identity-provider"); err != nil {
-		return err
+	if len(waiting) > 0 {
+		setCondition(instance, operatorv1alpha1.ConditionIMIntegrated, metav1.ConditionFalse, operatorv1alpha1.ReasonInProgress, "Waiting for the rollout of "+strings.Join(waiting, "; "))
+		return false, nil
 	}
-	klog.Infof(" platform-identity-provider pod is ready")
+	klog.Infof("Deployments %s are rolled out", strings.Join(imDeployments, ", "))
 
-	klog.Infof("MCSP operand resources created successfully")
-	return nil
+	return true, nil
 }
 
-func (r *AccountIAMReconciler) configIM(ctx context.Context, instance *operatorv1alpha1.AccountIAM) error {
+func (r *AccountIAMReconciler) configIM(ctx context.Context, instance *operatorv1alpha1.AccountIAM, bootstrapData *BootstrapSecret) (bool, error) {
 
-	host, err := r.getHost(ctx, "account-iam", instance.Namespace)
-	if err != nil {
-		return err
+	if done, err := r.updateIssuer(ctx, instance, bootstrapData); err != nil || !done {
+		return false, err
 	}
-	klog.Infof("account-iam route host: %s", host)
 
-	mcspHost := "https://" + host
-	encodedURL := base64.StdEncoding.EncodeToString([]byte(mcspHost))
-	BootstrapData.AccountIAMURL = encodedURL
+	accountIAMURL, err := r.endpointURL(ctx, instance, instance.Spec.Endpoints.AccountIAMURL, "account-iam")
+	if err != nil {
+		return false, err
+	}
+	bootstrapData.AccountIAMURL = base64.StdEncoding.EncodeToString([]byte(accountIAMURL))
+	instance.Status.Endpoints.AccountIAMURL = accountIAMURL
 
 	klog.Infof("Creating IM Config Job")
-	decodedData, err := r.decodeData(BootstrapData)
+	operandConfig, err := newOperandConfig(instance)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	if err := r.InjectData(ctx, instance, res.IMConfigYamls, decodedData); err != nil {
-		return err
+	decodedData, err := r.decodeData(*bootstrapData)
+	if err != nil {
+		return false, err
 	}
 
-	return nil
-}
+	jobs, err := r.registerOIDCClient(ctx, instance, TemplateData{decodedData, operandConfig})
+	if err != nil {
+		return false, err
+	}
 
-func (r *AccountIAMReconciler) InjectData(ctx context.Context, instance *operatorv1alpha1.AccountIAM, manifests []string, bootstrapData BootstrapSecret) error {
+	if err := r.InjectData(ctx, instance, res.IMConfigYamls, TemplateData{decodedData, operandConfig}); err != nil {
+		return false, err
+	}
 
-	var buffer bytes.Buffer
+	// the job runs again when the IM or the account-iam URL changes
+	sum := sha256.Sum256([]byte(decodedData.AccountIAMNamespace + "\n" + decodedData.IAMHOSTURL + "\n" + decodedData.AccountIAMURL))
+	imJob, err := r.reconcileJob(ctx, instance, res.IM_CONFIG_JOB, TemplateData{decodedData, operandConfig}, fmt.Sprintf("%x", sum[:8]))
+	if err != nil {
+		return false, err
+	}
+	setOperandsCondition(instance, operatorv1alpha1.ConditionIMIntegrated, append(jobs, imJob)...)
+
+	klog.Infof("MCSP operand resources created successfully")
+	return meta.IsStatusConditionTrue(instance.Status.Conditions, operatorv1alpha1.ConditionIMIntegrated), nil
+}
+
+func (r *AccountIAMReconciler) InjectData(ctx context.Context, instance *operatorv1alpha1.AccountIAM, manifests []string, data TemplateData) error {
 
 	// Loop through each secret manifest that requires data injection
 	for _, manifest := range manifests {
-		object := &unstructured.Unstructured{}
-		buffer.Reset()
-
-		// Parse the manifest template and execute it with the provided bootstrap data
-		t := template.Must(template.New("template resrouces").Parse(manifest))
-		if err := t.Execute(&buffer, bootstrapData); err != nil {
+		object, err := r.renderTemplate(instance, manifest, data)
+		if err != nil {
 			return err
 		}
 
-		if err := yaml.Unmarshal(buffer.Bytes(), object); err != nil {
+		if err := r.apply(ctx, instance, object); err != nil {
 			return err
 		}
+	}
 
-		object.SetNamespace(instance.Namespace)
-		if err := controllerutil.SetControllerReference(instance, object, r.Scheme); err != nil {
-			return err
-		}
+	return nil
+}
 
-		if err := r.createOrUpdate(ctx, object); err != nil {
-			return err
-		}
+// renderTemplate executes the manifest template with the data and returns
+// the object, in the namespace of the instance and controlled by it
+func (r *AccountIAMReconciler) renderTemplate(instance *operatorv1alpha1.AccountIAM, manifest string, data TemplateData) (*unstructured.Unstructured, error) {
+	var buffer bytes.Buffer
+	object := &unstructured.Unstructured{}
+
+	// Execute the manifest template, parsed at startup, with the provided data
+	t, err := res.Template(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Execute(&buffer, data); err != nil {
+		return nil, err
 	}
 
-	return nil
+	if err := yaml.Unmarshal(buffer.Bytes(), object); err != nil {
+		return nil, err
+	}
+
+	object.SetNamespace(instance.Namespace)
+	if err := controllerutil.SetControllerReference(instance, object, r.Scheme); err != nil {
+		return nil, err
+	}
+	return object, nil
 }
 
 func (r *AccountIAMReconciler) decodeData(data BootstrapSecret) (BootstrapSecret, error) {
@@ -481,144 +743,94 @@ func (r *AccountIAMReconciler) decodeData(data BootstrapSecret) (BootstrapSecret
 
 // CheckCRD returns true if the given crd is existent
 func (r *AccountIAMReconciler) CheckCRD(apiGroupVersion string, kind string) (bool, error) {
-	dc := discovery.NewDiscoveryClientForConfigOrDie(r.Config)
-	exist, err := r.ResourceExists(dc, apiGroupVersion, kind)
+	dc, err := r.discovery()
 	if err != nil {
 		return false, err
 	}
-	if !exist {
-		return false, nil
-	}
-	return true, nil
+	return r.ResourceExists(dc, apiGroupVersion, kind)
 }
 
-// ResourceExists returns true if the given resource kind exists
-// in the given api groupversion
-func (r *AccountIAMReconciler) ResourceExists(dc discovery.DiscoveryInterface, apiGroupVersion, kind string) (bool, error) {
-	_, apiLists, err := dc.ServerGroupsAndResources()
-	if err != nil {
-		return false, err
-	}
-	for _, apiList := range apiLists {
-		if apiList.GroupVersion == apiGroupVersion {
-			for _, r := range apiList.APIResources {
-				if r.Kind == kind {
-					return true, nil
-				}
-			}
-		}
-	}
-	return false, nil
-}
-
-// restart and check pod
-func (r *AccountIAMReconciler) restartAndCheckPod(ctx context.Context, ns, label string) error {
-	// restart platform-auth-service pod and wait for it to be ready
-
-	pod, err := r.getPodName(ctx, ns, label)
-	if err != nil {
-		return err
-	}
-
-	podName := &corev1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      pod,
-			Namespace: ns,
-		},
-	}
-	if err := r.Delete(ctx, podName); err != nil {
-		klog.Errorf("Failed to delete pod %s in namespace %s", label, ns)
-		return err
-	}
-
-	time.Sleep(10 * time.Second)
+// discovery returns the cached discovery client, which is created on first use
+func (r *AccountIAMReconciler) discovery() (discovery.CachedDiscoveryInterface, error) {
+	r.discoveryLock.Lock()
+	defer r.discoveryLock.Unlock()
 
-	if err := r.waitForPodReady(ctx, ns, label); err != nil {
-		return err
+	if r.discoveryClient == nil {
+		dc, err := discovery.NewDiscoveryClientForConfig(r.Config)
+		if err != nil {
+			return nil, err
+		}
+		r.discoveryClient = memory.NewMemCacheClient(dc)
 	}
-
-	return nil
+	return r.discoveryClient, nil
 }
 
-func (r *AccountIAMReconciler) getPodName(ctx context.Context, namespace, label string) (string, error) {
-	podList := &corev1.PodList{}
-	labelSelector := labels.SelectorFromSet(labels.Set{"app": label})
+// invalidateDiscovery makes the next CRD check discover the APIs again
+func (r *AccountIAMReconciler) invalidateDiscovery() {
+	r.discoveryLock.Lock()
+	defer r.discoveryLock.Unlock()
 
-	if err := r.Client.List(ctx, podList, &client.ListOptions{
-		Namespace:     namespace,
-		LabelSelector: labelSelector,
-	}); err != nil {
-		return "", err
+	if r.discoveryClient != nil {
+		r.discoveryClient.Invalidate()
 	}
-
-	if len(podList.Items) == 0 {
-		return "", fmt.Errorf("No pod found with label %s in namespace %s", labelSelector, namespace)
-	}
-	return podList.Items[0].Name, nil
 }
 
-func (r *AccountIAMReconciler) waitForPodReady(ctx context.Context, ns, label string) error {
-
-	return wait.PollImmediate(20*time.Second, 2*time.Minute, func() (bool, error) {
-		pod, err := r.getPodName(ctx, ns, label)
-		if err != nil {
-			return false, err
-		}
-		podName := &corev1.Pod{}
-		if err := r.Get(ctx, client.ObjectKey{Name: pod, Namespace: ns}, podName); err != nil {
-			return false, err
-		}
-
-		for _, cond := range podName.Status.Conditions {
-			klog.Infof("Waiting Pod %s to be ready...", pod)
-			if cond.Type == corev1.PodReady && cond.Status == corev1.ConditionTrue {
-				return true, nil
-			}
-		}
-
-		return false, nil
-	})
-}
-
-func (r *AccountIAMReconciler) createOrUpdate(ctx context.Context, obj *unstructured.Unstructured) error {
-	// err := r.Update(ctx, obj)
-	// if err != nil {
-	// 	if !k8serrors.IsNotFound(err) {
-	// 		return err
-	// 	}
-	// }
-	// if err == nil {
-	// 	return nil
-	// }
-
-	// only reachable if update DID see error IsNotFound
-	err := r.Create(ctx, obj)
+// ResourceExists returns true if the given resource kind exists
+// in the given api groupversion. Only the groupversion is looked up, so
+// that the discovery failing for other APIs, such as an aggregated API
+// whose server is down, does not matter.
+func (r *AccountIAMReconciler) ResourceExists(dc discovery.DiscoveryInterface, apiGroupVersion, kind string) (bool, error) {
+	apiList, err := dc.ServerResourcesForGroupVersion(apiGroupVersion)
 	if err != nil {
-		if !k8serrors.IsAlreadyExists(err) {
-			return err
+		if errors.Is(err, memory.ErrCacheNotFound) || k8serrors.IsNotFound(err) {
+			return false, nil
 		}
+		klog.Errorf("Failed to discover the resources of %s: %v", apiGroupVersion, err)
+		return false, err
 	}
-
-	if err == nil {
-		return nil
+	for _, r := range apiList.APIResources {
+		if r.Kind == kind {
+			return true, nil
+		}
 	}
+	return false, nil
+}
 
-	fromCluster := &unstructured.Unstructured{}
-	fromCluster.SetKind(obj.GetKind())
-	fromCluster.SetAPIVersion(obj.GetAPIVersion())
-	if err := r.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}, fromCluster); err != nil {
+// SetupWithManager sets up the controller with the Manager.
+func (r *AccountIAMReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &operatorv1alpha1.AccountIAM{}, referencedSecretsField, indexReferencedSecrets); err != nil {
 		return err
 	}
-	obj.SetResourceVersion(fromCluster.GetResourceVersion())
-	if err := r.Update(ctx, obj); err != nil {
+
+	c, err := ctrl.NewControllerManagedBy(mgr).
+		For(&operatorv1alpha1.AccountIAM{}).
+		Owns(&corev1.Secret{}).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.ServiceAccount{}).
+		Owns(&networkingv1.NetworkPolicy{}).
+		Owns(&batchv1.Job{}).
+		Owns(&batchv1.CronJob{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&rbacv1.Role{}).
+		Owns(&rbacv1.RoleBinding{}).
+		// Shared resources which are read, but not owned, by the AccountIAM in their namespace
+		Watches(&appsv1.Deployment{}, handler.EnqueueRequestsFromMapFunc(r.accountIAMsInNamespace), builder.WithPredicates(withName(imDeployments...))).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.accountIAMsInNamespace), builder.WithPredicates(withName("user-mgmt-bootstrap", bootstrapOverridesSecret))).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.accountIAMsInNamespace), builder.WithPredicates(withName("platform-auth-idp"))).
+		Watches(&networkingv1.Ingress{}, handler.EnqueueRequestsFromMapFunc(r.accountIAMsInNamespace), builder.WithPredicates(withName("cp-console", "account-iam"))).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.accountIAMsForReferencedSecret)).
+		// The jobs of the backup CronJob, which owns them
+		Watches(&batchv1.Job{}, handler.EnqueueRequestsFromMapFunc(r.accountIAMsInNamespace), builder.WithPredicates(withLabel(resources.DBBackupLabel))).
+		// The discovery of the optional APIs is refreshed when their CRDs change
+		Watches(crdMetadata(), handler.EnqueueRequestsFromMapFunc(r.optionalCRDChanged), builder.WithPredicates(withName(optionalCRDs...))).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
+		Build(r)
+	if err != nil {
 		return err
 	}
-	return nil
-}
 
-// SetupWithManager sets up the controller with the Manager.
-func (r *AccountIAMReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&operatorv1alpha1.AccountIAM{}).
-		Complete(r)
+	r.controller = c
+	r.cache = mgr.GetCache()
+	r.restMapper = mgr.GetRESTMapper()
+	return nil
 }
@@ -0,0 +1,224 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/csaupgrade"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+// fieldOwner is the field manager the operator applies the operand manifests with
+const fieldOwner = "ibm-user-management-operator"
+
+// legacyFieldManagers are the field managers of the updates the operator
+// makes outside of apply: those it made before it applied the manifests
+// server side, named after its binary, and its own, like scaling account-iam
+// down for a restore. The fields they own are taken back by the next apply.
+var legacyFieldManagers = sets.New("manager", fieldOwner)
+
+// apply applies the object server side with the field manager of the
+// operator, which owns the fields it renders. The fields other controllers
+// set are kept. A rendered field which another manager has changed is taken
+// back, with a Warning event on the instance.
+//
+// The hash of the rendered manifest is recorded in an annotation, and the
+// object is not written while it is unchanged and the live object still has
+// the rendered fields.
+func (r *AccountIAMReconciler) apply(ctx context.Context, instance *operatorv1alpha1.AccountIAM, obj *unstructured.Unstructured) error {
+	hash, err := manifestHash(obj)
+	if err != nil {
+		return err
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[resources.SpecHashAnnotation] = hash
+	obj.SetAnnotations(annotations)
+
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), live); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+	} else if live.GetAnnotations()[resources.SpecHashAnnotation] == hash && hasRenderedFields(live.Object, renderedFields(obj)) {
+		skippedObjects.WithLabelValues(obj.GetKind()).Inc()
+		return nil
+	}
+
+	if err := r.applyPatch(ctx, instance, obj); err != nil {
+		return err
+	}
+	appliedObjects.WithLabelValues(obj.GetKind()).Inc()
+	return nil
+}
+
+// applyPatch sends the object as an apply patch. On a conflict, the fields
+// the operator has updated before are taken over first, and the fields
+// another manager has changed are forced back to their rendered values.
+func (r *AccountIAMReconciler) applyPatch(ctx context.Context, instance *operatorv1alpha1.AccountIAM, obj *unstructured.Unstructured) error {
+	err := r.Patch(ctx, obj, client.Apply, client.FieldOwner(fieldOwner))
+	if !k8serrors.IsConflict(err) {
+		return err
+	}
+
+	// the fields the operator has updated before are taken over once
+	upgraded, upgradeErr := r.upgradeManagedFields(ctx, obj)
+	if upgradeErr != nil {
+		return upgradeErr
+	}
+	if upgraded {
+		err = r.Patch(ctx, obj, client.Apply, client.FieldOwner(fieldOwner))
+	}
+	if !k8serrors.IsConflict(err) {
+		return err
+	}
+
+	r.recordEvent(instance, corev1.EventTypeWarning, "FieldsTakenOver", "Reverting the fields of %s %s changed by another manager: %v", obj.GetKind(), obj.GetName(), err)
+	return r.Patch(ctx, obj, client.Apply, client.FieldOwner(fieldOwner), client.ForceOwnership)
+}
+
+// upgradeManagedFields moves the fields of the object which the legacy
+// field managers own to the field manager of the operator. It returns false
+// if they own none.
+func (r *AccountIAMReconciler) upgradeManagedFields(ctx context.Context, obj *unstructured.Unstructured) (bool, error) {
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(obj.GroupVersionKind())
+	if err := r.Get(ctx, client.ObjectKeyFromObject(obj), live); err != nil {
+		return false, err
+	}
+
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(live, legacyFieldManagers, fieldOwner)
+	if err != nil || patch == nil {
+		return false, err
+	}
+	klog.Infof("Taking over the fields of %s %s in namespace %s from the update field manager", obj.GetKind(), obj.GetName(), obj.GetNamespace())
+	if err := r.Patch(ctx, live, client.RawPatch(types.JSONPatchType, patch)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// renderedFields returns the fields of the rendered object as the API server
+// returns them: the stringData of a Secret is written to its data
+func renderedFields(obj *unstructured.Unstructured) map[string]interface{} {
+	stringData, found, _ := unstructured.NestedStringMap(obj.Object, "stringData")
+	if obj.GetKind() != "Secret" || !found {
+		return obj.Object
+	}
+	rendered := obj.DeepCopy().Object
+	delete(rendered, "stringData")
+	data, _, _ := unstructured.NestedMap(rendered, "data")
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	for key, value := range stringData {
+		data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	rendered["data"] = data
+	return rendered
+}
+
+// hasRenderedFields returns true if the live value has the rendered one: the
+// rendered fields of a map with the same values, and the lists of the same
+// length with each item having the rendered one. The fields only the live
+// object has, set by the API server or other controllers, are ignored.
+func hasRenderedFields(live, rendered interface{}) bool {
+	switch rendered := rendered.(type) {
+	case map[string]interface{}:
+		live, ok := live.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, value := range rendered {
+			liveValue, found := live[key]
+			if !found {
+				if value == nil {
+					continue
+				}
+				return false
+			}
+			if !hasRenderedFields(liveValue, value) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		live, ok := live.([]interface{})
+		if !ok || len(live) != len(rendered) {
+			return false
+		}
+		for i := range rendered {
+			if !hasRenderedFields(live[i], rendered[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		// the rendered numbers are decoded from YAML as floats, the live
+		// ones as integers
+		if liveNumber, ok := number(live); ok {
+			renderedNumber, ok := number(rendered)
+			return ok && liveNumber == renderedNumber
+		}
+		return live == rendered
+	}
+}
+
+// number returns the value as a float if it is a number
+func number(value interface{}) (float64, bool) {
+	switch value := value.(type) {
+	case int64:
+		return float64(value), true
+	case int32:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+	return 0, false
+}
+
+// manifestHash returns a short hash of the rendered manifest of the object,
+// leaving out the annotation it is recorded in
+func manifestHash(obj *unstructured.Unstructured) (string, error) {
+	rendered := obj.DeepCopy()
+	unstructured.RemoveNestedField(rendered.Object, "metadata", "annotations", resources.SpecHashAnnotation)
+	manifest, err := json.Marshal(rendered.Object)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(manifest)
+	return fmt.Sprintf("%x", sum[:8]), nil
+}
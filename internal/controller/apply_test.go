@@ -0,0 +1,138 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+)
+
+var _ = Describe("Server-side apply", func() {
+	ctx := context.Background()
+	const ns = "apply-test"
+
+	var r *AccountIAMReconciler
+	var recorder *record.FakeRecorder
+	var instance *operatorv1alpha1.AccountIAM
+
+	BeforeEach(func() {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, namespace))).To(Succeed())
+		instance = &operatorv1alpha1.AccountIAM{ObjectMeta: metav1.ObjectMeta{Name: "accountiam", Namespace: ns}}
+		recorder = record.NewFakeRecorder(10)
+		r = &AccountIAMReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Config: cfg, Recorder: recorder}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.DeleteAllOf(ctx, &corev1.ConfigMap{}, client.InNamespace(ns))).To(Succeed())
+	})
+
+	// renderedConfigMap is the ConfigMap as the operator renders it
+	renderedConfigMap := func(value string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": "account-iam-config", "namespace": ns},
+			"data":       map[string]interface{}{"rendered": value},
+		}}
+	}
+
+	getConfigMap := func() *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "account-iam-config", Namespace: ns}, cm)).To(Succeed())
+		return cm
+	}
+
+	It("should keep the fields set by other controllers", func() {
+		Expect(r.apply(ctx, instance, renderedConfigMap("1"))).To(Succeed())
+
+		cm := getConfigMap()
+		cm.Annotations = map[string]string{"service.beta.openshift.io/inject-cabundle": "true"}
+		cm.Data["service-ca.crt"] = "-----BEGIN CERTIFICATE-----"
+		Expect(k8sClient.Update(ctx, cm, client.FieldOwner("service-ca-operator"))).To(Succeed())
+
+		Expect(r.apply(ctx, instance, renderedConfigMap("2"))).To(Succeed())
+		cm = getConfigMap()
+		Expect(cm.Data).To(HaveKeyWithValue("rendered", "2"))
+		Expect(cm.Data).To(HaveKey("service-ca.crt"))
+		Expect(cm.Annotations).To(HaveKey("service.beta.openshift.io/inject-cabundle"))
+	})
+
+	It("should not write the objects whose rendered manifest is unchanged", func() {
+		Expect(r.apply(ctx, instance, renderedConfigMap("1"))).To(Succeed())
+		resourceVersion := getConfigMap().ResourceVersion
+		skipped := testutil.ToFloat64(skippedObjects.WithLabelValues("ConfigMap"))
+		applied := testutil.ToFloat64(appliedObjects.WithLabelValues("ConfigMap"))
+
+		Expect(r.apply(ctx, instance, renderedConfigMap("1"))).To(Succeed())
+		Expect(getConfigMap().ResourceVersion).To(Equal(resourceVersion))
+		Expect(testutil.ToFloat64(skippedObjects.WithLabelValues("ConfigMap"))).To(Equal(skipped + 1))
+
+		Expect(r.apply(ctx, instance, renderedConfigMap("2"))).To(Succeed())
+		Expect(getConfigMap().Data).To(HaveKeyWithValue("rendered", "2"))
+		Expect(testutil.ToFloat64(appliedObjects.WithLabelValues("ConfigMap"))).To(Equal(applied + 1))
+	})
+
+	It("should take back a rendered field another manager has changed", func() {
+		Expect(r.apply(ctx, instance, renderedConfigMap("1"))).To(Succeed())
+
+		cm := getConfigMap()
+		cm.Data["rendered"] = "edited"
+		Expect(k8sClient.Update(ctx, cm, client.FieldOwner("kubectl-edit"))).To(Succeed())
+
+		Expect(r.apply(ctx, instance, renderedConfigMap("1"))).To(Succeed())
+		Expect(getConfigMap().Data).To(HaveKeyWithValue("rendered", "1"))
+		Expect(recorder.Events).To(Receive(ContainSubstring("FieldsTakenOver")))
+	})
+
+	It("should take back the fields the operator has updated outside of apply", func() {
+		Expect(r.apply(ctx, instance, renderedConfigMap("1"))).To(Succeed())
+
+		cm := getConfigMap()
+		cm.Data["rendered"] = "quiesced"
+		Expect(k8sClient.Update(ctx, cm, client.FieldOwner(fieldOwner))).To(Succeed())
+
+		Expect(r.apply(ctx, instance, renderedConfigMap("1"))).To(Succeed())
+		Expect(getConfigMap().Data).To(HaveKeyWithValue("rendered", "1"))
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("should take over the fields the operator has updated before", func() {
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "account-iam-config", Namespace: ns},
+			Data:       map[string]string{"rendered": "1"},
+		}
+		Expect(k8sClient.Create(ctx, cm, client.FieldOwner("manager"))).To(Succeed())
+
+		Expect(r.apply(ctx, instance, renderedConfigMap("2"))).To(Succeed())
+		cm = getConfigMap()
+		Expect(cm.Data).To(HaveKeyWithValue("rendered", "2"))
+		for _, entry := range cm.ManagedFields {
+			Expect(entry.Manager).NotTo(Equal("manager"))
+		}
+	})
+})
@@ -0,0 +1,248 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+	res "github.com/IBM/ibm-user-management-operator/internal/resources/yamls"
+)
+
+// backupCronJob is the name of the CronJob which backs up the database
+const backupCronJob = "account-iam-db-backup"
+
+// reconcileBackup creates the CronJob which backs up the database when a
+// schedule is set, and deletes it otherwise. It reports the last backup of
+// the CronJob in the status.
+func (r *AccountIAMReconciler) reconcileBackup(ctx context.Context, instance *operatorv1alpha1.AccountIAM, data TemplateData) error {
+	if data.BackupSchedule == "" {
+		cronJob := &batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: backupCronJob, Namespace: instance.Namespace}}
+		if err := r.Delete(ctx, cronJob); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := r.InjectData(ctx, instance, []string{res.DB_BACKUP_CRONJOB}, data); err != nil {
+		return err
+	}
+	return r.updateBackupStatus(ctx, instance)
+}
+
+// updateBackupStatus reports the last backup, and the last one which
+// succeeded, from the jobs the CronJob has kept. A failed backup is reported
+// once in a Warning event.
+func (r *AccountIAMReconciler) updateBackupStatus(ctx context.Context, instance *operatorv1alpha1.AccountIAM) error {
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.InNamespace(instance.Namespace), client.MatchingLabels{resources.DBBackupLabel: "account-iam"}); err != nil {
+		return err
+	}
+
+	status := &instance.Status.Database.Backup
+	var last *batchv1.Job
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if last == nil || last.CreationTimestamp.Before(&job.CreationTimestamp) {
+			last = job
+		}
+		result, finished := backupResultOf(job)
+		if result == operatorv1alpha1.BackupSucceeded && (status.LastSuccessfulBackupTime == nil || status.LastSuccessfulBackupTime.Before(finished)) {
+			status.LastSuccessfulBackup = job.Name
+			status.LastSuccessfulBackupTime = finished
+		}
+	}
+	if last == nil {
+		return nil
+	}
+
+	result, finished := backupResultOf(last)
+	if result == operatorv1alpha1.BackupFailed && (status.LastBackup != last.Name || status.LastBackupResult != result) {
+		klog.Errorf("Backup %s of the account-iam database failed: %s", last.Name, jobStatusOf(last).Message)
+		r.recordEvent(instance, corev1.EventTypeWarning, "BackupFailed", "Backup %s of the account-iam database failed: %s", last.Name, jobStatusOf(last).Message)
+	}
+	status.LastBackup = last.Name
+	status.LastBackupResult = result
+	status.LastBackupTime = finished
+	return nil
+}
+
+// backupResultOf returns the result of the job of a backup, and when it
+// finished, or started while it runs
+func backupResultOf(job *batchv1.Job) (operatorv1alpha1.BackupResult, *metav1.Time) {
+	switch jobStatusOf(job).Status {
+	case operatorv1alpha1.OperandReady:
+		return operatorv1alpha1.BackupSucceeded, job.Status.CompletionTime
+	case operatorv1alpha1.OperandFailed:
+		for _, cond := range job.Status.Conditions {
+			if cond.Type == batchv1.JobFailed {
+				return operatorv1alpha1.BackupFailed, &cond.LastTransitionTime
+			}
+		}
+		return operatorv1alpha1.BackupFailed, nil
+	}
+	return operatorv1alpha1.BackupRunning, job.Status.StartTime
+}
+
+// restoreDatabase restores the backup requested in the spec once. account-iam
+// is scaled down first, so that nothing writes to the database while the job
+// restores it, and back to its replicas once the job has finished. It returns true once
+// there is no restore to run, and then the schema is migrated again if the
+// backup was restored. A failed restore leaves the database as it was.
+func (r *AccountIAMReconciler) restoreDatabase(ctx context.Context, instance *operatorv1alpha1.AccountIAM, data TemplateData) (bool, error) {
+	restore := data.BackupRestore
+	status := &instance.Status.Database.Backup
+	if restore == "" || restore == status.Restore {
+		return true, nil
+	}
+
+	quiesced, err := r.quiesceApp(ctx, instance.Namespace)
+	if err != nil {
+		return false, err
+	}
+	if !quiesced {
+		message := "Waiting for account-iam to scale down to restore backup " + restore
+		setCondition(instance, operatorv1alpha1.ConditionDatabaseRestored, metav1.ConditionFalse, operatorv1alpha1.ReasonInProgress, message)
+		setCondition(instance, operatorv1alpha1.ConditionDatabaseReady, metav1.ConditionFalse, operatorv1alpha1.ReasonInProgress, message)
+		return false, nil
+	}
+
+	klog.Infof("Restoring backup %s of the account-iam database", restore)
+	job, err := r.reconcileJob(ctx, instance, res.DB_RESTORE_JOB, data, restore)
+	if err != nil {
+		return false, err
+	}
+	setOperandStatus(instance, job)
+
+	now := metav1.Now()
+	switch job.Status {
+	case operatorv1alpha1.OperandNotReady:
+		message := "Waiting for Job " + job.Name + " to restore backup " + restore
+		setCondition(instance, operatorv1alpha1.ConditionDatabaseRestored, metav1.ConditionFalse, operatorv1alpha1.ReasonInProgress, message)
+		setCondition(instance, operatorv1alpha1.ConditionDatabaseReady, metav1.ConditionFalse, operatorv1alpha1.ReasonInProgress, message)
+		return false, nil
+	case operatorv1alpha1.OperandFailed:
+		klog.Errorf("Failed to restore backup %s of the account-iam database: %s", restore, job.Message)
+		r.recordEvent(instance, corev1.EventTypeWarning, "DatabaseRestoreFailed", "Failed to restore backup %s, the database is left as it was: %s", restore, job.Message)
+		setCondition(instance, operatorv1alpha1.ConditionDatabaseRestored, metav1.ConditionFalse, operatorv1alpha1.ReasonOperandFailed, job.Message)
+		status.RestoreResult = operatorv1alpha1.BackupFailed
+	default:
+		r.recordEvent(instance, corev1.EventTypeNormal, "DatabaseRestored", "Restored backup %s of the account-iam database", restore)
+		setCondition(instance, operatorv1alpha1.ConditionDatabaseRestored, metav1.ConditionTrue, operatorv1alpha1.ReasonSucceeded, "")
+		status.RestoreResult = operatorv1alpha1.BackupSucceeded
+		// the backup may be of an older schema
+		dbStatus := &instance.Status.Database
+		dbStatus.MigrationImage = ""
+		dbStatus.MigrationVersion = ""
+		dbStatus.MigrationTime = nil
+	}
+	if err := r.resumeApp(ctx, instance.Namespace); err != nil {
+		return false, err
+	}
+	status.Restore = restore
+	status.RestoreTime = &now
+	return true, nil
+}
+
+// quiesceApp scales the account-iam application down to no replicas. It
+// returns true once its pods are gone.
+func (r *AccountIAMReconciler) quiesceApp(ctx context.Context, ns string) (bool, error) {
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(schema.FromAPIVersionAndKind(resources.WebSphereAPIGroupVersion, resources.WebSphereKind))
+	if err := r.Get(ctx, client.ObjectKey{Name: "account-iam", Namespace: ns}, app); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	replicas, found, err := unstructured.NestedInt64(app.Object, "spec", "replicas")
+	if err != nil {
+		return false, err
+	}
+	if !found || replicas != 0 {
+		klog.Infof("Scaling down %s account-iam in namespace %s", resources.WebSphereKind, ns)
+		annotations := app.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		if _, ok := annotations[resources.RestoreReplicasAnnotation]; !ok {
+			annotations[resources.RestoreReplicasAnnotation] = ""
+			if found {
+				annotations[resources.RestoreReplicasAnnotation] = strconv.FormatInt(replicas, 10)
+			}
+			app.SetAnnotations(annotations)
+		}
+		if err := unstructured.SetNestedField(app.Object, int64(0), "spec", "replicas"); err != nil {
+			return false, err
+		}
+		// with the field manager of the operator, so that the next apply
+		// takes the replicas back
+		if err := r.Update(ctx, app, client.FieldOwner(fieldOwner)); err != nil {
+			return false, err
+		}
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(ns), client.MatchingLabels{"app.kubernetes.io/instance": "account-iam"}); err != nil {
+		return false, err
+	}
+	return len(pods.Items) == 0, nil
+}
+
+// resumeApp scales the account-iam application back to the replicas it had
+// before quiesceApp scaled it down
+func (r *AccountIAMReconciler) resumeApp(ctx context.Context, ns string) error {
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(schema.FromAPIVersionAndKind(resources.WebSphereAPIGroupVersion, resources.WebSphereKind))
+	if err := r.Get(ctx, client.ObjectKey{Name: "account-iam", Namespace: ns}, app); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	annotations := app.GetAnnotations()
+	value, ok := annotations[resources.RestoreReplicasAnnotation]
+	if !ok {
+		return nil
+	}
+
+	if value == "" {
+		unstructured.RemoveNestedField(app.Object, "spec", "replicas")
+	} else {
+		replicas, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid annotation %s of %s account-iam: %w", resources.RestoreReplicasAnnotation, resources.WebSphereKind, err)
+		}
+		if err := unstructured.SetNestedField(app.Object, replicas, "spec", "replicas"); err != nil {
+			return err
+		}
+	}
+	delete(annotations, resources.RestoreReplicasAnnotation)
+	app.SetAnnotations(annotations)
+	klog.Infof("Scaling up %s account-iam in namespace %s", resources.WebSphereKind, ns)
+	return r.Update(ctx, app, client.FieldOwner(fieldOwner))
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+var _ = Describe("Database backups", func() {
+	ctx := context.Background()
+	const ns = "backup-test"
+
+	var r *AccountIAMReconciler
+	var recorder *record.FakeRecorder
+	var instance *operatorv1alpha1.AccountIAM
+
+	BeforeEach(func() {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, namespace))).To(Succeed())
+
+		instance = &operatorv1alpha1.AccountIAM{
+			ObjectMeta: metav1.ObjectMeta{Name: "accountiam", Namespace: ns},
+			Spec: operatorv1alpha1.AccountIAMSpec{
+				Database: operatorv1alpha1.DatabaseSpec{
+					Backup: operatorv1alpha1.BackupSpec{
+						Schedule: "0 2 * * *",
+						PVC:      &operatorv1alpha1.BackupPVCSpec{ClaimName: "account-iam-backups"},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+
+		recorder = record.NewFakeRecorder(10)
+		r = &AccountIAMReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Config: cfg, Recorder: recorder}
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, instance))).To(Succeed())
+		Expect(k8sClient.DeleteAllOf(ctx, &batchv1.Job{}, client.InNamespace(ns))).To(Succeed())
+		Expect(k8sClient.DeleteAllOf(ctx, &batchv1.CronJob{}, client.InNamespace(ns))).To(Succeed())
+		Expect(k8sClient.DeleteAllOf(ctx, &corev1.Pod{}, client.InNamespace(ns), client.GracePeriodSeconds(0))).To(Succeed())
+		Expect(k8sClient.DeleteAllOf(ctx, newLibertyApp(), client.InNamespace(ns))).To(Succeed())
+	})
+
+	// createBackupJob creates a job of the backup CronJob which has
+	// finished with the condition, at the time
+	createBackupJob := func(name string, condType batchv1.JobConditionType, finished time.Time) {
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns, Labels: map[string]string{resources.DBBackupLabel: "account-iam"}},
+			Spec: batchv1.JobSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						RestartPolicy: corev1.RestartPolicyNever,
+						Containers:    []corev1.Container{{Name: "pg-dump", Image: "postgres"}},
+					},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, job)).To(Succeed())
+
+		start := metav1.NewTime(finished.Add(-time.Minute))
+		job.Status.StartTime = &start
+		job.Status.Conditions = []batchv1.JobCondition{{
+			Type:               condType,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.NewTime(finished),
+			Message:            "pg_dump exited with 1",
+		}}
+		if condType == batchv1.JobComplete {
+			completion := metav1.NewTime(finished)
+			job.Status.CompletionTime = &completion
+		}
+		Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+	}
+
+	It("should create the CronJob for the schedule and delete it without one", func() {
+		operandConfig, err := newOperandConfig(instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.reconcileBackup(ctx, instance, TemplateData{OperandConfig: operandConfig})).To(Succeed())
+
+		cronJob := &batchv1.CronJob{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: backupCronJob, Namespace: ns}, cronJob)).To(Succeed())
+		Expect(cronJob.Spec.Schedule).To(Equal("0 2 * * *"))
+		Expect(cronJob.Spec.ConcurrencyPolicy).To(Equal(batchv1.ForbidConcurrent))
+		pod := cronJob.Spec.JobTemplate.Spec.Template.Spec
+		Expect(pod.InitContainers).To(HaveLen(1))
+		Expect(pod.Containers).To(HaveLen(1))
+		Expect(pod.Containers[0].Name).To(Equal("prune"))
+		Expect(pod.Volumes[0].PersistentVolumeClaim.ClaimName).To(Equal("account-iam-backups"))
+
+		instance.Spec.Database.Backup.Schedule = ""
+		operandConfig, err = newOperandConfig(instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.reconcileBackup(ctx, instance, TemplateData{OperandConfig: operandConfig})).To(Succeed())
+		Expect(errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKey{Name: backupCronJob, Namespace: ns}, cronJob))).To(BeTrue())
+	})
+
+	It("should report the last backup and the last successful one", func() {
+		now := time.Now().Truncate(time.Second)
+		createBackupJob("account-iam-db-backup-1", batchv1.JobComplete, now.Add(-2*time.Hour))
+		// the creation timestamps of the jobs are to the second
+		time.Sleep(time.Second)
+		createBackupJob("account-iam-db-backup-2", batchv1.JobFailed, now.Add(-time.Hour))
+
+		Expect(r.updateBackupStatus(ctx, instance)).To(Succeed())
+		status := instance.Status.Database.Backup
+		Expect(status.LastBackup).To(Equal("account-iam-db-backup-2"))
+		Expect(status.LastBackupResult).To(Equal(operatorv1alpha1.BackupFailed))
+		Expect(status.LastBackupTime.Time).To(BeTemporally("==", now.Add(-time.Hour)))
+		Expect(status.LastSuccessfulBackup).To(Equal("account-iam-db-backup-1"))
+		Expect(status.LastSuccessfulBackupTime.Time).To(BeTemporally("==", now.Add(-2*time.Hour)))
+		Expect(recorder.Events).To(Receive(ContainSubstring("BackupFailed")))
+
+		By("Reporting the failed backup once")
+		Expect(r.updateBackupStatus(ctx, instance)).To(Succeed())
+		Expect(recorder.Events).NotTo(Receive())
+	})
+
+	It("should render the upload to S3 with the credentials and the prefix quoted", func() {
+		instance.Spec.Database.Backup.PVC = nil
+		instance.Spec.Database.Backup.S3 = &operatorv1alpha1.BackupS3Spec{
+			Endpoint:          "http://minio.minio.svc:9000/",
+			Bucket:            "account-iam",
+			Prefix:            "/tenant: a #1/",
+			CredentialsSecret: "account-iam-s3",
+		}
+		operandConfig, err := newOperandConfig(instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r.reconcileBackup(ctx, instance, TemplateData{OperandConfig: operandConfig})).To(Succeed())
+
+		cronJob := &batchv1.CronJob{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: backupCronJob, Namespace: ns}, cronJob)).To(Succeed())
+		pod := cronJob.Spec.JobTemplate.Spec.Template.Spec
+		Expect(pod.Containers).To(HaveLen(1))
+		upload := pod.Containers[0]
+		Expect(upload.Name).To(Equal("upload"))
+		Expect(upload.Image).To(Equal(resources.DefaultBackupS3Image))
+		env := map[string]corev1.EnvVar{}
+		for _, e := range upload.Env {
+			env[e.Name] = e
+		}
+		Expect(env["S3_ENDPOINT"].Value).To(Equal("http://minio.minio.svc:9000"))
+		Expect(env["S3_BUCKET"].Value).To(Equal("account-iam"))
+		Expect(env["S3_PREFIX"].Value).To(Equal("tenant: a #1/"))
+		for _, key := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY"} {
+			Expect(env[key].ValueFrom.SecretKeyRef.Name).To(Equal("account-iam-s3"))
+			Expect(env[key].ValueFrom.SecretKeyRef.Key).To(Equal(key))
+		}
+		Expect(pod.Volumes[0].EmptyDir).NotTo(BeNil())
+	})
+
+	Context("restore", func() {
+		var data TemplateData
+
+		BeforeEach(func() {
+			instance.Spec.Database.Backup.Restore = "account-iam-db-backup-1"
+			operandConfig, err := newOperandConfig(instance)
+			Expect(err).NotTo(HaveOccurred())
+			data = TemplateData{OperandConfig: operandConfig}
+
+			app := newLibertyApp()
+			app.SetName("account-iam")
+			app.SetNamespace(ns)
+			Expect(unstructured.SetNestedField(app.Object, int64(3), "spec", "replicas")).To(Succeed())
+			Expect(k8sClient.Create(ctx, app)).To(Succeed())
+
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "account-iam-0", Namespace: ns, Labels: map[string]string{"app.kubernetes.io/instance": "account-iam"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "account-iam"}}},
+			}
+			Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+		})
+
+		getApp := func() *unstructured.Unstructured {
+			app := newLibertyApp()
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "account-iam", Namespace: ns}, app)).To(Succeed())
+			return app
+		}
+
+		expectReplicas := func(expected int64) {
+			replicas, _, err := unstructured.NestedInt64(getApp().Object, "spec", "replicas")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(replicas).To(Equal(expected))
+		}
+
+		getRestoreJob := func() *batchv1.Job {
+			job := &batchv1.Job{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "restore-account-iam-db", Namespace: ns}, job)).To(Succeed())
+			return job
+		}
+
+		// startRestore runs the restore up to the job, which is left running
+		startRestore := func() types.UID {
+			By("Scaling account-iam down and waiting for its pods to stop")
+			Expect(r.restoreDatabase(ctx, instance, data)).To(BeFalse())
+			expectReplicas(0)
+			Expect(getApp().GetAnnotations()).To(HaveKeyWithValue(resources.RestoreReplicasAnnotation, "3"))
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKey{Name: "restore-account-iam-db", Namespace: ns}, &batchv1.Job{}))).To(BeTrue())
+			cond := meta.FindStatusCondition(instance.Status.Conditions, operatorv1alpha1.ConditionDatabaseRestored)
+			Expect(cond.Message).To(Equal("Waiting for account-iam to scale down to restore backup account-iam-db-backup-1"))
+
+			By("Running the job once the pods are gone")
+			Expect(k8sClient.Delete(ctx, &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "account-iam-0", Namespace: ns}}, client.GracePeriodSeconds(0))).To(Succeed())
+			Eventually(func() bool {
+				return errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKey{Name: "account-iam-0", Namespace: ns}, &corev1.Pod{}))
+			}).Should(BeTrue())
+			Expect(r.restoreDatabase(ctx, instance, data)).To(BeFalse())
+			uid := getRestoreJob().UID
+			Expect(r.restoreDatabase(ctx, instance, data)).To(BeFalse())
+			Expect(getRestoreJob().UID).To(Equal(uid))
+			expectReplicas(0)
+			return uid
+		}
+
+		// finishRestoreJob sets the status of the restore job as its
+		// controller would once it has finished with the condition
+		finishRestoreJob := func(condType batchv1.JobConditionType) {
+			job := getRestoreJob()
+			now := metav1.Now()
+			start := metav1.NewTime(now.Add(-time.Minute))
+			job.Status.StartTime = &start
+			job.Status.Conditions = []batchv1.JobCondition{{
+				Type:               condType,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: now,
+				Message:            "pg_restore exited with 1",
+			}}
+			if condType == batchv1.JobComplete {
+				job.Status.CompletionTime = &now
+			}
+			Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+		}
+
+		It("should restore the backup once and scale account-iam back up", func() {
+			uid := startRestore()
+
+			finishRestoreJob(batchv1.JobComplete)
+			Expect(r.restoreDatabase(ctx, instance, data)).To(BeTrue())
+			status := instance.Status.Database.Backup
+			Expect(status.Restore).To(Equal("account-iam-db-backup-1"))
+			Expect(status.RestoreResult).To(Equal(operatorv1alpha1.BackupSucceeded))
+			Expect(status.RestoreTime).NotTo(BeNil())
+			Expect(meta.IsStatusConditionTrue(instance.Status.Conditions, operatorv1alpha1.ConditionDatabaseRestored)).To(BeTrue())
+			Expect(recorder.Events).To(Receive(ContainSubstring("DatabaseRestored")))
+			expectReplicas(3)
+			Expect(getApp().GetAnnotations()).NotTo(HaveKey(resources.RestoreReplicasAnnotation))
+
+			By("Not restoring the same backup again")
+			Expect(r.restoreDatabase(ctx, instance, data)).To(BeTrue())
+			Expect(getRestoreJob().UID).To(Equal(uid))
+			expectReplicas(3)
+		})
+
+		It("should scale account-iam back up after a failed restore", func() {
+			startRestore()
+
+			finishRestoreJob(batchv1.JobFailed)
+			Expect(r.restoreDatabase(ctx, instance, data)).To(BeTrue())
+			status := instance.Status.Database.Backup
+			Expect(status.Restore).To(Equal("account-iam-db-backup-1"))
+			Expect(status.RestoreResult).To(Equal(operatorv1alpha1.BackupFailed))
+			cond := meta.FindStatusCondition(instance.Status.Conditions, operatorv1alpha1.ConditionDatabaseRestored)
+			Expect(cond.Reason).To(Equal(operatorv1alpha1.ReasonOperandFailed))
+			Expect(recorder.Events).To(Receive(ContainSubstring("DatabaseRestoreFailed")))
+			expectReplicas(3)
+			Expect(getApp().GetAnnotations()).NotTo(HaveKey(resources.RestoreReplicasAnnotation))
+		})
+	})
+})
+
+// newLibertyApp returns an empty WebSphereLibertyApplication
+func newLibertyApp() *unstructured.Unstructured {
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(schema.FromAPIVersionAndKind(resources.WebSphereAPIGroupVersion, resources.WebSphereKind))
+	return app
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+// bootstrapOverridesSecret is the optional secret with the values of the
+// bootstrap secret set by the user. Its keys are those of user-mgmt-bootstrap.
+const bootstrapOverridesSecret = "user-mgmt-bootstrap-overrides"
+
+// generatedBootstrapKeys are the keys of the bootstrap secret which are
+// generated when missing and kept as they are afterwards
+var generatedBootstrapKeys = []string{"ClientSecret", "PGPassword"}
+
+// managedBootstrapKeys are the keys of the bootstrap secret which the
+// database user was set up with, so they cannot be overridden
+var managedBootstrapKeys = []string{"PGPassword", "PGPasswordPending"}
+
+// hostDerivedBootstrapKeys are the keys of the bootstrap secret derived from
+// the IM URL, which the operator has always recomputed, so a value which
+// differs from the derived one is stale rather than set by the user
+var hostDerivedBootstrapKeys = []string{"DiscoveryEndpoint", "DefaultIDPValue", "GlobalAccountIDP", "IAMHOSTURL"}
+
+// derivedBootstrapData returns the values of the bootstrap secret which are
+// derived from the spec of the instance and the IM URL
+func derivedBootstrapData(instance *operatorv1alpha1.AccountIAM, imURL string) map[string][]byte {
+	realm := stringOrDefault(instance.Spec.AccountIAM.Realm, resources.DefaultRealm)
+	clientID := stringOrDefault(instance.Spec.AccountIAM.ClientID, resources.DefaultClientID)
+	discoveryEndpoint := stringOrDefault(instance.Spec.Endpoints.DiscoveryEndpoint, imURL+"/idprovider/v1/auth/.well-known/openid-configuration")
+
+	return map[string][]byte{
+		"Realm":               []byte(realm),
+		"ClientID":            []byte(clientID),
+		"DiscoveryEndpoint":   []byte(discoveryEndpoint),
+		"UserValidationAPIV2": []byte("https://openshift.default.svc/apis/user.openshift.io/v1/users/~"),
+		"DefaultAUDValue":     []byte(clientID),
+		"DefaultIDPValue":     []byte(imURL + "/idprovider/v1/auth"),
+		"DefaultRealmValue":   []byte(realm),
+		"SREMCSPGroupsToken":  []byte("mcsp-im-integration-admin"),
+		"GlobalRealmValue":    []byte(realm),
+		"GlobalAccountIDP":    []byte(imURL + "/idprovider/v1/auth"),
+		"GlobalAccountAud":    []byte(clientID),
+		"AccountIAMNamespace": []byte(instance.Namespace),
+		"IAMHOSTURL":          []byte(imURL),
+	}
+}
+
+// keepGeneratedBootstrapData copies the generated values and the pending
+// password of the current bootstrap secret into the data, and generates the
+// values which are missing
+func keepGeneratedBootstrapData(current, data map[string][]byte) error {
+	if pending, ok := current["PGPasswordPending"]; ok {
+		data["PGPasswordPending"] = pending
+	}
+	for _, key := range generatedBootstrapKeys {
+		if len(current[key]) > 0 {
+			data[key] = current[key]
+			continue
+		}
+		value, err := generatePassword()
+		if err != nil {
+			return err
+		}
+		data[key] = value
+	}
+	return nil
+}
+
+// applyBootstrapOverrides sets the values of the overrides secret in the
+// data, except those of the keys managed by the operator
+func (r *AccountIAMReconciler) applyBootstrapOverrides(ctx context.Context, ns string, data map[string][]byte) error {
+	overrides := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: bootstrapOverridesSecret, Namespace: ns}, overrides); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	for key, value := range overrides.Data {
+		if slices.Contains(managedBootstrapKeys, key) {
+			klog.Warningf("Ignoring key %s of secret %s in namespace %s, it is managed by the operator", key, bootstrapOverridesSecret, ns)
+			continue
+		}
+		data[key] = value
+	}
+	return nil
+}
+
+// migrateBootstrapOverrides copies the values set by the user in a bootstrap
+// secret from before the overrides secret existed, which differ from the
+// derived ones, into the overrides secret, so that recomputing the derived
+// values does not discard them. The values derived from the IM URL are left
+// out, they are stale after a change of the host. The values already in the
+// overrides secret are kept. The bootstrap secret is marked once they are
+// copied.
+func (r *AccountIAMReconciler) migrateBootstrapOverrides(ctx context.Context, instance *operatorv1alpha1.AccountIAM, bootstrapsecret *corev1.Secret, derived map[string][]byte) error {
+	if bootstrapsecret.Annotations[resources.BootstrapOverridesMigratedAnnotation] == "true" {
+		return nil
+	}
+
+	ns := instance.Namespace
+	userSet := map[string][]byte{}
+	for key, value := range derived {
+		if slices.Contains(hostDerivedBootstrapKeys, key) {
+			continue
+		}
+		if current, ok := bootstrapsecret.Data[key]; ok && !bytes.Equal(current, value) {
+			userSet[key] = current
+		}
+	}
+
+	if len(userSet) > 0 {
+		overrides := &corev1.Secret{}
+		err := r.Get(ctx, client.ObjectKey{Name: bootstrapOverridesSecret, Namespace: ns}, overrides)
+		if err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+		exists := err == nil
+		if !exists {
+			overrides = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: bootstrapOverridesSecret, Namespace: ns},
+				Type:       corev1.SecretTypeOpaque,
+			}
+		}
+		if overrides.Data == nil {
+			overrides.Data = map[string][]byte{}
+		}
+		var keys []string
+		for key, value := range userSet {
+			if _, ok := overrides.Data[key]; !ok {
+				overrides.Data[key] = value
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		if len(keys) > 0 {
+			klog.Infof("Copying %s of secret user-mgmt-bootstrap into secret %s in namespace %s", strings.Join(keys, ", "), bootstrapOverridesSecret, ns)
+			if exists {
+				err = r.Update(ctx, overrides)
+			} else {
+				err = r.Create(ctx, overrides)
+			}
+			if err != nil {
+				return err
+			}
+			r.recordEvent(instance, corev1.EventTypeNormal, "BootstrapOverridesMigrated", "Copied the values of %s set in secret user-mgmt-bootstrap into secret %s", strings.Join(keys, ", "), bootstrapOverridesSecret)
+		}
+	}
+
+	if bootstrapsecret.Annotations == nil {
+		bootstrapsecret.Annotations = map[string]string{}
+	}
+	bootstrapsecret.Annotations[resources.BootstrapOverridesMigratedAnnotation] = "true"
+	return r.Update(ctx, bootstrapsecret)
+}
+
+// keepUnknownBootstrapData copies the values of the current bootstrap secret
+// under the keys the operator does not know of into the data
+func keepUnknownBootstrapData(current, derived, data map[string][]byte) {
+	for key, value := range current {
+		if _, ok := derived[key]; ok || slices.Contains(generatedBootstrapKeys, key) || slices.Contains(managedBootstrapKeys, key) {
+			continue
+		}
+		if _, ok := data[key]; !ok {
+			data[key] = value
+		}
+	}
+}
+
+// changedKeys returns the sorted keys whose values differ between the two maps
+func changedKeys(current, desired map[string][]byte) []string {
+	var keys []string
+	for key, value := range desired {
+		if old, ok := current[key]; !ok || !bytes.Equal(old, value) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range current {
+		if _, ok := desired[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// BootstrapHash returns a hash of the bootstrap values account-iam is
+// configured with, so that the application rolls when one of them changes.
+// The pending password and the account-iam URL are left out, as the
+// application does not read them.
+func (b BootstrapSecret) BootstrapHash() string {
+	b.PGPasswordPending = ""
+	b.AccountIAMURL = ""
+
+	h := sha256.New()
+	val := reflect.ValueOf(b)
+	for i := 0; i < val.NumField(); i++ {
+		fmt.Fprintf(h, "%s=%s\n", val.Type().Field(i).Name, val.Field(i).String())
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)[:8])
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+func TestUserSetBootstrapValuesSurviveTheUpgrade(t *testing.T) {
+	ctx := context.Background()
+	const ns = "bootstrap"
+	const imURL = "https://cp-console.example.com"
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	instance := &operatorv1alpha1.AccountIAM{ObjectMeta: metav1.ObjectMeta{Name: "accountiam", Namespace: ns}}
+
+	// a bootstrap secret of a release without the overrides secret, with
+	// the realm and the SRE token customized and a key of its own
+	data := derivedBootstrapData(instance, imURL)
+	data["Realm"] = []byte("CustomRealm")
+	data["SREMCSPGroupsToken"] = []byte("custom-sre-token")
+	data["CustomKey"] = []byte("custom-value")
+	data["ClientSecret"] = []byte("generated-client-secret")
+	data["PGPassword"] = []byte("generated-password")
+	bootstrapsecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "user-mgmt-bootstrap", Namespace: ns}, Data: data}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(bootstrapsecret).Build()
+	r := &AccountIAMReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+
+	secret, err := r.initBootstrapData(ctx, instance, imURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range map[string]string{
+		"Realm":              "CustomRealm",
+		"SREMCSPGroupsToken": "custom-sre-token",
+		"CustomKey":          "custom-value",
+		"PGPassword":         "generated-password",
+	} {
+		if string(secret.Data[key]) != value {
+			t.Errorf("%s of the bootstrap secret is %q, expected %q", key, secret.Data[key], value)
+		}
+	}
+	if secret.Annotations[resources.BootstrapOverridesMigratedAnnotation] != "true" {
+		t.Errorf("bootstrap secret is not marked as migrated")
+	}
+
+	overrides := &corev1.Secret{}
+	if err := c.Get(ctx, client.ObjectKey{Name: bootstrapOverridesSecret, Namespace: ns}, overrides); err != nil {
+		t.Fatal(err)
+	}
+	if len(overrides.Data) != 2 || string(overrides.Data["Realm"]) != "CustomRealm" || string(overrides.Data["SREMCSPGroupsToken"]) != "custom-sre-token" {
+		t.Errorf("overrides secret has %v, expected the customized Realm and SREMCSPGroupsToken", overrides.Data)
+	}
+
+	// the overrides secret is the place of the user-set values from then on
+	delete(overrides.Data, "Realm")
+	if err := c.Update(ctx, overrides); err != nil {
+		t.Fatal(err)
+	}
+	secret, err = r.initBootstrapData(ctx, instance, imURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(secret.Data["Realm"]) != resources.DefaultRealm {
+		t.Errorf("Realm of the bootstrap secret is %q, expected the derived %q", secret.Data["Realm"], resources.DefaultRealm)
+	}
+}
+
+func TestHostDerivedBootstrapValuesAreRecomputedAfterTheUpgrade(t *testing.T) {
+	ctx := context.Background()
+	const ns = "bootstrap-host"
+	const oldURL = "https://cp-console.old.example.com"
+	const imURL = "https://cp-console.example.com"
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	instance := &operatorv1alpha1.AccountIAM{ObjectMeta: metav1.ObjectMeta{Name: "accountiam", Namespace: ns}}
+
+	// a bootstrap secret of a release without the overrides secret, derived
+	// from the cp-console host before it changed
+	data := derivedBootstrapData(instance, oldURL)
+	data["ClientSecret"] = []byte("generated-client-secret")
+	data["PGPassword"] = []byte("generated-password")
+	bootstrapsecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "user-mgmt-bootstrap", Namespace: ns}, Data: data}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(bootstrapsecret).Build()
+	r := &AccountIAMReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+
+	secret, err := r.initBootstrapData(ctx, instance, imURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	derived := derivedBootstrapData(instance, imURL)
+	for _, key := range hostDerivedBootstrapKeys {
+		if string(secret.Data[key]) != string(derived[key]) {
+			t.Errorf("%s of the bootstrap secret is %q, expected the derived %q", key, secret.Data[key], derived[key])
+		}
+	}
+
+	overrides := &corev1.Secret{}
+	err = c.Get(ctx, client.ObjectKey{Name: bootstrapOverridesSecret, Namespace: ns}, overrides)
+	if err == nil {
+		t.Errorf("overrides secret created with %v, expected none", overrides.Data)
+	} else if !k8serrors.IsNotFound(err) {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+// edbHealthyPhase is the phase of an EDB Cluster whose instances are all ready
+const edbHealthyPhase = "Cluster in healthy state"
+
+// checkDBCluster finds the EDB Cluster which hosts the database and reports
+// its state in the status. It returns true once the Cluster has a healthy
+// primary, its superuser secret and read-write service exist, and the CA
+// secret to verify it with has the CA certificate.
+func (r *AccountIAMReconciler) checkDBCluster(ctx context.Context, instance *operatorv1alpha1.AccountIAM) (bool, error) {
+	gvk := schema.FromAPIVersionAndKind(resources.EDBAPIGroupVersion, resources.EDBClusterKind)
+	if err := r.watchOptional(gvk, handler.EnqueueRequestsFromMapFunc(r.accountIAMsInNamespace)); err != nil {
+		return false, err
+	}
+
+	cluster, err := r.findDBCluster(ctx, instance)
+	if err != nil || cluster == nil {
+		instance.Status.Database.Cluster = operatorv1alpha1.DBClusterStatus{}
+		return false, err
+	}
+
+	status := dbClusterStatusOf(cluster)
+	instance.Status.Database.Cluster = status
+	if !dbClusterHealthy(cluster) {
+		return dbClusterNotReady(instance, "EDB Cluster %s has no healthy primary: %s", status.Name, status.Phase)
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: status.SuperuserSecret, Namespace: instance.Namespace}, secret); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return false, err
+		}
+		return dbClusterNotReady(instance, "Secret %s with the superuser of EDB Cluster %s not found", status.SuperuserSecret, status.Name)
+	}
+
+	service := &corev1.Service{}
+	if err := r.Get(ctx, client.ObjectKey{Name: status.RWService, Namespace: instance.Namespace}, service); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return false, err
+		}
+		return dbClusterNotReady(instance, "Service %s of EDB Cluster %s not found", status.RWService, status.Name)
+	}
+
+	caSecret := dbClusterCASecret(instance, status)
+	if err := r.Get(ctx, client.ObjectKey{Name: caSecret, Namespace: instance.Namespace}, secret); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return false, err
+		}
+		return dbClusterNotReady(instance, "Secret %s with the CA certificate of EDB Cluster %s not found", caSecret, status.Name)
+	}
+	if len(secret.Data["ca.crt"]) == 0 {
+		return dbClusterNotReady(instance, "Secret %s with the CA certificate of EDB Cluster %s has no ca.crt", caSecret, status.Name)
+	}
+	return true, nil
+}
+
+// dbClusterCASecret returns the CA secret of the spec, or else the one of
+// the EDB Cluster
+func dbClusterCASecret(instance *operatorv1alpha1.AccountIAM, cluster operatorv1alpha1.DBClusterStatus) string {
+	if instance.Spec.Database.CASecret != "" {
+		return instance.Spec.Database.CASecret
+	}
+	return stringOrDefault(cluster.CASecret, resources.DefaultDBCluster+"-ca")
+}
+
+// findDBCluster returns the EDB Cluster named in the spec, or else the one
+// of Common Services, or else the only one of the namespace. It returns nil
+// with the condition set when there is no such Cluster.
+func (r *AccountIAMReconciler) findDBCluster(ctx context.Context, instance *operatorv1alpha1.AccountIAM) (*unstructured.Unstructured, error) {
+	gvk := schema.FromAPIVersionAndKind(resources.EDBAPIGroupVersion, resources.EDBClusterKind)
+	name := stringOrDefault(instance.Spec.Database.Cluster, resources.DefaultDBCluster)
+
+	cluster := &unstructured.Unstructured{}
+	cluster.SetGroupVersionKind(gvk)
+	err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: instance.Namespace}, cluster)
+	if err == nil {
+		return cluster, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+	if instance.Spec.Database.Cluster != "" {
+		_, err := dbClusterNotReady(instance, "EDB Cluster %s not found", name)
+		return nil, err
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err := r.List(ctx, list, client.InNamespace(instance.Namespace)); err != nil {
+		return nil, err
+	}
+	switch len(list.Items) {
+	case 0:
+		_, err := dbClusterNotReady(instance, "No EDB Cluster found in namespace %s", instance.Namespace)
+		return nil, err
+	case 1:
+		klog.Infof("Using EDB Cluster %s in namespace %s", list.Items[0].GetName(), instance.Namespace)
+		return &list.Items[0], nil
+	}
+	_, err = dbClusterNotReady(instance, "Found %d EDB Clusters in namespace %s, set spec.database.cluster to choose one", len(list.Items), instance.Namespace)
+	return nil, err
+}
+
+// dbClusterStatusOf returns the state of the EDB Cluster reported in the status
+func dbClusterStatusOf(cluster *unstructured.Unstructured) operatorv1alpha1.DBClusterStatus {
+	status := operatorv1alpha1.DBClusterStatus{
+		Name:      cluster.GetName(),
+		RWService: cluster.GetName() + "-rw",
+	}
+	status.Phase, _, _ = unstructured.NestedString(cluster.Object, "status", "phase")
+	status.CurrentPrimary, _, _ = unstructured.NestedString(cluster.Object, "status", "currentPrimary")
+	status.Instances, _, _ = unstructured.NestedInt64(cluster.Object, "status", "instances")
+	status.ReadyInstances, _, _ = unstructured.NestedInt64(cluster.Object, "status", "readyInstances")
+
+	// EDB names the superuser secret after the Cluster, unless its spec sets it
+	superuserSecret, _, _ := unstructured.NestedString(cluster.Object, "spec", "superuserSecret", "name")
+	status.SuperuserSecret = stringOrDefault(superuserSecret, cluster.GetName()+"-superuser")
+	// and so the CA secret of its server certificate
+	caSecret, _, _ := unstructured.NestedString(cluster.Object, "spec", "certificates", "serverCASecret")
+	status.CASecret = stringOrDefault(caSecret, cluster.GetName()+"-ca")
+	return status
+}
+
+// dbClusterHealthy returns true if the EDB Cluster has a primary and reports
+// itself ready, by its Ready condition or else by its phase
+func dbClusterHealthy(cluster *unstructured.Unstructured) bool {
+	primary, _, _ := unstructured.NestedString(cluster.Object, "status", "currentPrimary")
+	if primary == "" {
+		return false
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(cluster.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if ok && cond["type"] == "Ready" {
+			return cond["status"] == string(metav1.ConditionTrue)
+		}
+	}
+	phase, _, _ := unstructured.NestedString(cluster.Object, "status", "phase")
+	return phase == edbHealthyPhase
+}
+
+// dbClusterNotReady sets the DatabaseReady condition to False with the
+// message, and returns that the database step has not completed
+func dbClusterNotReady(instance *operatorv1alpha1.AccountIAM, format string, args ...interface{}) (bool, error) {
+	message := fmt.Sprintf(format, args...)
+	klog.Infof("Waiting for the database of AccountIAM %s/%s: %s", instance.Namespace, instance.Name, message)
+	setCondition(instance, operatorv1alpha1.ConditionDatabaseReady, metav1.ConditionFalse, operatorv1alpha1.ReasonDBClusterNotReady, message)
+	return false, nil
+}
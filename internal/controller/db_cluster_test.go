@@ -0,0 +1,127 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+var _ = Describe("EDB Cluster", func() {
+	ctx := context.Background()
+	const ns = "db-cluster-test"
+
+	var r *AccountIAMReconciler
+	var instance *operatorv1alpha1.AccountIAM
+
+	BeforeEach(func() {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, namespace))).To(Succeed())
+		instance = &operatorv1alpha1.AccountIAM{ObjectMeta: metav1.ObjectMeta{Name: "accountiam", Namespace: ns}}
+		r = &AccountIAMReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Config: cfg}
+	})
+
+	AfterEach(func() {
+		cluster := &unstructured.Unstructured{}
+		cluster.SetGroupVersionKind(schema.FromAPIVersionAndKind(resources.EDBAPIGroupVersion, resources.EDBClusterKind))
+		Expect(k8sClient.DeleteAllOf(ctx, cluster, client.InNamespace(ns))).To(Succeed())
+	})
+
+	createCluster := func(name string) {
+		cluster := &unstructured.Unstructured{}
+		cluster.SetGroupVersionKind(schema.FromAPIVersionAndKind(resources.EDBAPIGroupVersion, resources.EDBClusterKind))
+		cluster.SetName(name)
+		cluster.SetNamespace(ns)
+		Expect(k8sClient.Create(ctx, cluster)).To(Succeed())
+	}
+
+	It("should take the Cluster named in the spec", func() {
+		createCluster(resources.DefaultDBCluster)
+		createCluster("account-iam-db")
+		instance.Spec.Database.Cluster = "account-iam-db"
+
+		cluster, err := r.findDBCluster(ctx, instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.GetName()).To(Equal("account-iam-db"))
+	})
+
+	It("should not fall back to another Cluster than the one named in the spec", func() {
+		createCluster(resources.DefaultDBCluster)
+		instance.Spec.Database.Cluster = "account-iam-db"
+
+		cluster, err := r.findDBCluster(ctx, instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster).To(BeNil())
+		expectDatabaseNotReady(instance, operatorv1alpha1.ReasonDBClusterNotReady, "EDB Cluster account-iam-db not found")
+	})
+
+	It("should take the Cluster of Common Services over the others", func() {
+		createCluster("account-iam-db")
+		createCluster(resources.DefaultDBCluster)
+
+		cluster, err := r.findDBCluster(ctx, instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.GetName()).To(Equal(resources.DefaultDBCluster))
+	})
+
+	It("should take the only Cluster of the namespace", func() {
+		createCluster("account-iam-db")
+
+		cluster, err := r.findDBCluster(ctx, instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster.GetName()).To(Equal("account-iam-db"))
+	})
+
+	It("should ask to choose one of several Clusters", func() {
+		createCluster("account-iam-db")
+		createCluster("other-db")
+
+		cluster, err := r.findDBCluster(ctx, instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster).To(BeNil())
+		expectDatabaseNotReady(instance, operatorv1alpha1.ReasonDBClusterNotReady,
+			"Found 2 EDB Clusters in namespace "+ns+", set spec.database.cluster to choose one")
+	})
+
+	It("should wait for a Cluster", func() {
+		cluster, err := r.findDBCluster(ctx, instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cluster).To(BeNil())
+		expectDatabaseNotReady(instance, operatorv1alpha1.ReasonDBClusterNotReady, "No EDB Cluster found in namespace "+ns)
+	})
+})
+
+// expectDatabaseNotReady checks the DatabaseReady condition the database
+// step has set while waiting
+func expectDatabaseNotReady(instance *operatorv1alpha1.AccountIAM, reason, message string) {
+	cond := meta.FindStatusCondition(instance.Status.Conditions, operatorv1alpha1.ConditionDatabaseReady)
+	Expect(cond).NotTo(BeNil())
+	Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+	Expect(cond.Reason).To(Equal(reason))
+	Expect(cond.Message).To(Equal(message))
+}
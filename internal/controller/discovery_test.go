@@ -0,0 +1,184 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+// discoveryServer serves the discovery of the APIs the operator checks for,
+// except HTTPRoute, and of an aggregated API whose server is down. It counts
+// the requests it serves.
+func discoveryServer(t testing.TB) (*httptest.Server, *atomic.Int64) {
+	served := map[string]string{
+		resources.EDBAPIGroupVersion:       resources.EDBClusterKind,
+		resources.WebSphereAPIGroupVersion: resources.WebSphereKind,
+		resources.RouteAPIGroupVersion:     resources.RouteKind,
+	}
+	const unavailable = "metrics.example.com/v1beta1"
+
+	groups := &metav1.APIGroupList{}
+	for _, groupVersion := range append([]string{unavailable}, resources.EDBAPIGroupVersion, resources.WebSphereAPIGroupVersion, resources.RouteAPIGroupVersion) {
+		gv, err := schema.ParseGroupVersion(groupVersion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		version := metav1.GroupVersionForDiscovery{GroupVersion: groupVersion, Version: gv.Version}
+		groups.Groups = append(groups.Groups, metav1.APIGroup{
+			Name:             gv.Group,
+			Versions:         []metav1.GroupVersionForDiscovery{version},
+			PreferredVersion: version,
+		})
+	}
+
+	requests := &atomic.Int64{}
+	writeJSON := func(w http.ResponseWriter, obj interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(obj); err != nil {
+			t.Error(err)
+		}
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests.Add(1)
+		path := strings.TrimSuffix(req.URL.Path, "/")
+		switch {
+		case path == "/api":
+			writeJSON(w, &metav1.APIVersions{Versions: []string{"v1"}})
+		case path == "/api/v1":
+			writeJSON(w, &metav1.APIResourceList{GroupVersion: "v1", APIResources: []metav1.APIResource{{Name: "secrets", Kind: "Secret", Namespaced: true}}})
+		case path == "/apis":
+			writeJSON(w, groups)
+		case path == "/apis/"+unavailable:
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		case strings.HasPrefix(path, "/apis/"):
+			groupVersion := strings.TrimPrefix(path, "/apis/")
+			kind, ok := served[groupVersion]
+			if !ok {
+				http.NotFound(w, req)
+				return
+			}
+			writeJSON(w, &metav1.APIResourceList{
+				GroupVersion: groupVersion,
+				APIResources: []metav1.APIResource{{Name: strings.ToLower(kind) + "s", Kind: kind, Namespaced: true}},
+			})
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+// checkPrereqCRDs makes the CRD checks of a reconcile
+func checkPrereqCRDs(r *AccountIAMReconciler) (map[string]bool, error) {
+	exist := map[string]bool{}
+	for _, apiVersionKind := range [][2]string{
+		{resources.EDBAPIGroupVersion, resources.EDBClusterKind},
+		{resources.WebSphereAPIGroupVersion, resources.WebSphereKind},
+		{resources.RouteAPIGroupVersion, resources.RouteKind},
+		{resources.HTTPRouteAPIGroupVersion, resources.HTTPRouteKind},
+	} {
+		ok, err := r.CheckCRD(apiVersionKind[0], apiVersionKind[1])
+		if err != nil {
+			return nil, err
+		}
+		exist[apiVersionKind[1]] = ok
+	}
+	return exist, nil
+}
+
+func TestCheckCRDCachesDiscovery(t *testing.T) {
+	server, requests := discoveryServer(t)
+	r := &AccountIAMReconciler{Config: &rest.Config{Host: server.URL}}
+
+	exist, err := checkPrereqCRDs(r)
+	if err != nil {
+		t.Fatalf("the CRD checks failed on the API whose server is down: %v", err)
+	}
+	for kind, want := range map[string]bool{
+		resources.EDBClusterKind: true,
+		resources.WebSphereKind:  true,
+		resources.RouteKind:      true,
+		resources.HTTPRouteKind:  false,
+	} {
+		if exist[kind] != want {
+			t.Errorf("CheckCRD of %s = %v, want %v", kind, exist[kind], want)
+		}
+	}
+
+	// the checks of the next reconciles are served from the cache
+	cold := requests.Load()
+	if _, err := checkPrereqCRDs(r); err != nil {
+		t.Fatal(err)
+	}
+	if warm := requests.Load() - cold; warm != 0 {
+		t.Errorf("the CRD checks of a reconcile made %d requests with the discovery cached, want 0", warm)
+	}
+
+	r.invalidateDiscovery()
+	if _, err := checkPrereqCRDs(r); err != nil {
+		t.Fatal(err)
+	}
+	if refreshed := requests.Load() - cold; refreshed != cold {
+		t.Errorf("the CRD checks made %d requests after the discovery was invalidated, want %d", refreshed, cold)
+	}
+}
+
+// BenchmarkCheckCRD reports the cost of the CRD checks of a reconcile, with
+// the discovery cached and with it invalidated before each reconcile, which
+// is what every reconcile cost before the cache
+func BenchmarkCheckCRD(b *testing.B) {
+	for _, bench := range []struct {
+		name       string
+		invalidate bool
+	}{
+		{name: "cached"},
+		{name: "invalidated", invalidate: true},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			server, requests := discoveryServer(b)
+			// the rate limits of the config of the manager
+			r := &AccountIAMReconciler{Config: &rest.Config{Host: server.URL, QPS: 20, Burst: 30}}
+			if _, err := checkPrereqCRDs(r); err != nil {
+				b.Fatal(err)
+			}
+
+			requests.Store(0)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if bench.invalidate {
+					r.invalidateDiscovery()
+				}
+				if _, err := checkPrereqCRDs(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(requests.Load())/float64(b.N), "requests/reconcile")
+		})
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+)
+
+// loadExternalDBCredentials replaces the database user and password in the
+// operand config and bootstrap data with those of the credentials secret of
+// the external database. It returns false with the condition set when the
+// credentials secret, or the CA secret, is missing or incomplete.
+func (r *AccountIAMReconciler) loadExternalDBCredentials(ctx context.Context, instance *operatorv1alpha1.AccountIAM, bootstrapData *BootstrapSecret, operandConfig *OperandConfig) (bool, error) {
+	external := instance.Spec.Database.External
+
+	credentials, ready, err := r.externalDBSecret(ctx, instance, external.CredentialsSecret, "username", "password")
+	if err != nil || !ready {
+		return false, err
+	}
+	if external.CASecret != "" {
+		if _, ready, err := r.externalDBSecret(ctx, instance, external.CASecret, "ca.crt"); err != nil || !ready {
+			return false, err
+		}
+	}
+
+	password := base64.StdEncoding.EncodeToString(credentials.Data["password"])
+	r.Redactor.AddSecrets(string(credentials.Data["password"]), password)
+
+	operandConfig.DBUser = string(credentials.Data["username"])
+	bootstrapData.PGPassword = password
+	return true, nil
+}
+
+// externalDBSecret returns the secret of the external database, or false
+// with the condition set when it is missing one of the keys
+func (r *AccountIAMReconciler) externalDBSecret(ctx context.Context, instance *operatorv1alpha1.AccountIAM, name string, keys ...string) (*corev1.Secret, bool, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: instance.Namespace}, secret); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return nil, false, err
+		}
+		externalDBNotReady(instance, fmt.Sprintf("Secret %s of the external database not found in namespace %s", name, instance.Namespace))
+		return nil, false, nil
+	}
+
+	var missing []string
+	for _, key := range keys {
+		if len(secret.Data[key]) == 0 {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		externalDBNotReady(instance, fmt.Sprintf("Secret %s of the external database is missing the keys %s", name, strings.Join(missing, ", ")))
+		return nil, false, nil
+	}
+	return secret, true, nil
+}
+
+// externalDBNotReady sets the DatabaseReady condition to False with the message
+func externalDBNotReady(instance *operatorv1alpha1.AccountIAM, message string) {
+	klog.Infof("Waiting for the database of AccountIAM %s/%s: %s", instance.Namespace, instance.Name, message)
+	setCondition(instance, operatorv1alpha1.ConditionDatabaseReady, metav1.ConditionFalse, operatorv1alpha1.ReasonInvalidDBCredentials, message)
+}
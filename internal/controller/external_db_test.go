@@ -0,0 +1,106 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+var _ = Describe("External database", func() {
+	ctx := context.Background()
+	const ns = "external-db-test"
+
+	var r *AccountIAMReconciler
+	var instance *operatorv1alpha1.AccountIAM
+	var bootstrapData *BootstrapSecret
+	var operandConfig OperandConfig
+
+	BeforeEach(func() {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, namespace))).To(Succeed())
+
+		instance = &operatorv1alpha1.AccountIAM{
+			ObjectMeta: metav1.ObjectMeta{Name: "accountiam", Namespace: ns},
+			Spec: operatorv1alpha1.AccountIAMSpec{
+				Database: operatorv1alpha1.DatabaseSpec{
+					External: &operatorv1alpha1.ExternalDatabaseSpec{
+						Host:              "postgres.example.com",
+						CredentialsSecret: "account-iam-db-credentials",
+						CASecret:          "account-iam-db-ca",
+					},
+				},
+			},
+		}
+		bootstrapData = &BootstrapSecret{}
+		var err error
+		operandConfig, err = newOperandConfig(instance)
+		Expect(err).NotTo(HaveOccurred())
+		r = &AccountIAMReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Config: cfg}
+	})
+
+	AfterEach(func() {
+		Expect(k8sClient.DeleteAllOf(ctx, &corev1.Secret{}, client.InNamespace(ns))).To(Succeed())
+	})
+
+	createSecret := func(name string, data map[string]string) {
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}, StringData: data}
+		Expect(k8sClient.Create(ctx, secret)).To(Succeed())
+	}
+
+	It("should wait for the credentials secret", func() {
+		Expect(r.loadExternalDBCredentials(ctx, instance, bootstrapData, &operandConfig)).To(BeFalse())
+		expectDatabaseNotReady(instance, operatorv1alpha1.ReasonInvalidDBCredentials,
+			"Secret account-iam-db-credentials of the external database not found in namespace "+ns)
+	})
+
+	It("should wait for the keys of the credentials secret", func() {
+		createSecret("account-iam-db-credentials", map[string]string{"username": "account_iam"})
+
+		Expect(r.loadExternalDBCredentials(ctx, instance, bootstrapData, &operandConfig)).To(BeFalse())
+		expectDatabaseNotReady(instance, operatorv1alpha1.ReasonInvalidDBCredentials,
+			"Secret account-iam-db-credentials of the external database is missing the keys password")
+	})
+
+	It("should wait for the CA certificate", func() {
+		createSecret("account-iam-db-credentials", map[string]string{"username": "account_iam", "password": "s3cr3t-password"})
+		createSecret("account-iam-db-ca", map[string]string{"tls.crt": "-----BEGIN CERTIFICATE-----"})
+
+		Expect(r.loadExternalDBCredentials(ctx, instance, bootstrapData, &operandConfig)).To(BeFalse())
+		expectDatabaseNotReady(instance, operatorv1alpha1.ReasonInvalidDBCredentials,
+			"Secret account-iam-db-ca of the external database is missing the keys ca.crt")
+	})
+
+	It("should load the credentials", func() {
+		createSecret("account-iam-db-credentials", map[string]string{"username": "account_iam", "password": "s3cr3t-password"})
+		createSecret("account-iam-db-ca", map[string]string{"ca.crt": "-----BEGIN CERTIFICATE-----"})
+
+		Expect(r.loadExternalDBCredentials(ctx, instance, bootstrapData, &operandConfig)).To(BeTrue())
+		Expect(operandConfig.DBUser).To(Equal("account_iam"))
+		Expect(bootstrapData.PGPassword).To(Equal(base64.StdEncoding.EncodeToString([]byte("s3cr3t-password"))))
+		Expect(operandConfig.DBSSLMode).To(Equal(resources.VerifiedDBSSLMode))
+	})
+})
@@ -0,0 +1,143 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+	res "github.com/IBM/ibm-user-management-operator/internal/resources/yamls"
+)
+
+// finalize tears down what the owner references of the instance do not
+// cover: first the IM issuer is restored, then the database is dropped or
+// retained by the deletion policy. An external database is always retained.
+// It has completed once the finalizer can be removed.
+func (r *AccountIAMReconciler) finalize(ctx context.Context, instance *operatorv1alpha1.AccountIAM) (bool, error) {
+	klog.Infof("Finalizing AccountIAM %s/%s", instance.Namespace, instance.Name)
+
+	if err := r.restoreIssuer(ctx, instance); err != nil {
+		return false, err
+	}
+
+	if instance.Spec.Database.External != nil {
+		r.recordEvent(instance, corev1.EventTypeNormal, "DatabaseRetained", "Retained the external database, which the operator never drops")
+		return true, nil
+	}
+	if instance.Spec.Database.DeletionPolicy != operatorv1alpha1.DeletionPolicyDelete {
+		r.recordEvent(instance, corev1.EventTypeNormal, "DatabaseRetained", "Retained the account-iam database and its user")
+		return true, nil
+	}
+	return r.dropDatabase(ctx, instance)
+}
+
+// restoreIssuer sets OIDC_ISSUER_URL in platform-auth-idp back to the value
+// recorded before account-iam changed it, and rolls the IM deployments out to
+// pick it up. The record is removed last, so that an interrupted restore is
+// retried. It is a no-op without a record.
+func (r *AccountIAMReconciler) restoreIssuer(ctx context.Context, instance *operatorv1alpha1.AccountIAM) error {
+	idpconfig := &corev1.ConfigMap{}
+	if err := r.Get(ctx, client.ObjectKey{Name: "platform-auth-idp", Namespace: instance.Namespace}, idpconfig); err != nil {
+		if k8serrors.IsNotFound(err) {
+			klog.Infof("ConfigMap platform-auth-idp not found in namespace %s, skip restoring the issuer", instance.Namespace)
+			return nil
+		}
+		return err
+	}
+	originalIssuer, ok := idpconfig.Annotations[resources.OriginalIssuerAnnotation]
+	if !ok {
+		return nil
+	}
+
+	if idpconfig.Data["OIDC_ISSUER_URL"] != originalIssuer {
+		klog.Infof("Restoring OIDC_ISSUER_URL in ConfigMap platform-auth-idp to %s", originalIssuer)
+		if idpconfig.Data == nil {
+			idpconfig.Data = map[string]string{}
+		}
+		idpconfig.Data["OIDC_ISSUER_URL"] = originalIssuer
+		if err := r.Update(ctx, idpconfig); err != nil {
+			klog.Errorf("Failed to update ConfigMap platform-auth-idp in namespace %s: %v", instance.Namespace, err)
+			return err
+		}
+	}
+
+	for _, name := range imDeployments {
+		if err := r.rolloutIssuer(ctx, instance.Namespace, name, originalIssuer); err != nil && !k8serrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	delete(idpconfig.Annotations, resources.OriginalIssuerAnnotation)
+	if err := r.Update(ctx, idpconfig); err != nil {
+		klog.Errorf("Failed to update ConfigMap platform-auth-idp in namespace %s: %v", instance.Namespace, err)
+		return err
+	}
+	r.recordEvent(instance, corev1.EventTypeNormal, "IssuerRestored", "Restored OIDC_ISSUER_URL in platform-auth-idp to %s and rolled out the IM deployments", originalIssuer)
+	return nil
+}
+
+// dropDatabase runs the job which drops the database and its user, then
+// deletes the bootstrap secret holding the password of the dropped user. It
+// has completed once both are gone.
+func (r *AccountIAMReconciler) dropDatabase(ctx context.Context, instance *operatorv1alpha1.AccountIAM) (bool, error) {
+	operandConfig, err := newOperandConfig(instance)
+	if err != nil {
+		return false, err
+	}
+	// drop the database which was bootstrapped, even if the spec has changed since
+	if instance.Status.Database.BootstrappedDatabase != "" {
+		operandConfig.DBName = instance.Status.Database.BootstrappedDatabase
+	}
+
+	superuser := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: operandConfig.DBSuperuserSecret, Namespace: instance.Namespace}, superuser); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return false, err
+		}
+		// the database went away with the EDB cluster, there is nothing to drop
+		r.recordEvent(instance, corev1.EventTypeWarning, "DatabaseNotDropped", "Secret %s not found, skip dropping database %s", operandConfig.DBSuperuserSecret, operandConfig.DBName)
+	} else {
+		job, err := r.reconcileJob(ctx, instance, res.DB_DROP_JOB, TemplateData{OperandConfig: operandConfig}, operandConfig.DBName)
+		if err != nil {
+			return false, err
+		}
+		switch job.Status {
+		case operatorv1alpha1.OperandFailed:
+			r.recordEvent(instance, corev1.EventTypeWarning, "DropDatabaseFailed", "Failed to drop database %s: %s", operandConfig.DBName, job.Message)
+			return false, fmt.Errorf("failed to drop database %s: %s", operandConfig.DBName, job.Message)
+		case operatorv1alpha1.OperandNotReady:
+			klog.Infof("Waiting for job %s to drop database %s", job.Name, operandConfig.DBName)
+			return false, nil
+		}
+		r.recordEvent(instance, corev1.EventTypeNormal, "DatabaseDropped", "Dropped database %s and user %s", operandConfig.DBName, operandConfig.DBUser)
+	}
+
+	bootstrapsecret := &corev1.Secret{}
+	bootstrapsecret.Name = "user-mgmt-bootstrap"
+	bootstrapsecret.Namespace = instance.Namespace
+	if err := r.Delete(ctx, bootstrapsecret); err != nil && !k8serrors.IsNotFound(err) {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	ocproute "github.com/openshift/api/route/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+// hostResolver reads the host of cp-console or account-iam from the object
+// of the same name. It returns a NotFound error when the object is missing.
+type hostResolver interface {
+	provider() operatorv1alpha1.HostProvider
+	host(ctx context.Context, name, ns string) (string, error)
+}
+
+// routeHosts reads the hosts from the OpenShift Routes
+type routeHosts struct {
+	client.Reader
+}
+
+func (routeHosts) provider() operatorv1alpha1.HostProvider {
+	return operatorv1alpha1.HostProviderRoute
+}
+
+func (h routeHosts) host(ctx context.Context, name, ns string) (string, error) {
+	route := &ocproute.Route{}
+	if err := h.Get(ctx, client.ObjectKey{Name: name, Namespace: ns}, route); err != nil {
+		return "", err
+	}
+	if route.Spec.Host == "" {
+		return "", fmt.Errorf("route %s in namespace %s has no host", name, ns)
+	}
+	return route.Spec.Host, nil
+}
+
+// httpRouteHosts reads the hosts from the Gateway API HTTPRoutes, which are
+// read unstructured as their API is optional
+type httpRouteHosts struct {
+	client.Reader
+}
+
+func (httpRouteHosts) provider() operatorv1alpha1.HostProvider {
+	return operatorv1alpha1.HostProviderHTTPRoute
+}
+
+func (h httpRouteHosts) host(ctx context.Context, name, ns string) (string, error) {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(schema.FromAPIVersionAndKind(resources.HTTPRouteAPIGroupVersion, resources.HTTPRouteKind))
+	if err := h.Get(ctx, client.ObjectKey{Name: name, Namespace: ns}, route); err != nil {
+		return "", err
+	}
+	hostnames, _, err := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	if err != nil {
+		return "", err
+	}
+	for _, hostname := range hostnames {
+		if hostname != "" {
+			return hostname, nil
+		}
+	}
+	return "", fmt.Errorf("HTTPRoute %s in namespace %s has no hostname", name, ns)
+}
+
+// ingressHosts reads the hosts from the Ingresses
+type ingressHosts struct {
+	client.Reader
+}
+
+func (ingressHosts) provider() operatorv1alpha1.HostProvider {
+	return operatorv1alpha1.HostProviderIngress
+}
+
+func (h ingressHosts) host(ctx context.Context, name, ns string) (string, error) {
+	ingress := &networkingv1.Ingress{}
+	if err := h.Get(ctx, client.ObjectKey{Name: name, Namespace: ns}, ingress); err != nil {
+		return "", err
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host != "" {
+			return rule.Host, nil
+		}
+	}
+	for _, tls := range ingress.Spec.TLS {
+		for _, host := range tls.Hosts {
+			if host != "" {
+				return host, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("ingress %s in namespace %s has no host", name, ns)
+}
+
+// specHosts takes the hosts from the spec of the AccountIAM
+type specHosts struct {
+	hosts operatorv1alpha1.HostsSpec
+}
+
+func (specHosts) provider() operatorv1alpha1.HostProvider {
+	return operatorv1alpha1.HostProviderSpec
+}
+
+func (h specHosts) host(_ context.Context, name, _ string) (string, error) {
+	var host, field string
+	switch name {
+	case "cp-console":
+		host, field = h.hosts.ConsoleHost, "consoleHost"
+	case "account-iam":
+		host, field = h.hosts.AccountIAMHost, "accountIAMHost"
+	default:
+		return "", fmt.Errorf("no host of %s in the spec", name)
+	}
+	if host == "" {
+		return "", fmt.Errorf("spec.hosts.%s must be set when spec.hosts.provider is Spec", field)
+	}
+	return host, nil
+}
+
+// hostResolvers returns the resolvers to read the hosts with, in order. It is
+// the one of the provider in the spec, or the ones whose API is available.
+func (r *AccountIAMReconciler) hostResolvers(instance *operatorv1alpha1.AccountIAM) ([]hostResolver, error) {
+	switch instance.Spec.Hosts.Provider {
+	case operatorv1alpha1.HostProviderRoute:
+		return []hostResolver{routeHosts{r.Client}}, nil
+	case operatorv1alpha1.HostProviderHTTPRoute:
+		return []hostResolver{httpRouteHosts{r.Client}}, nil
+	case operatorv1alpha1.HostProviderIngress:
+		return []hostResolver{ingressHosts{r.Client}}, nil
+	case operatorv1alpha1.HostProviderSpec:
+		return []hostResolver{specHosts{instance.Spec.Hosts}}, nil
+	}
+
+	var resolvers []hostResolver
+	existRoute, err := r.CheckCRD(resources.RouteAPIGroupVersion, resources.RouteKind)
+	if err != nil {
+		return nil, err
+	}
+	if existRoute {
+		resolvers = append(resolvers, routeHosts{r.Client})
+	}
+	existHTTPRoute, err := r.CheckCRD(resources.HTTPRouteAPIGroupVersion, resources.HTTPRouteKind)
+	if err != nil {
+		return nil, err
+	}
+	if existHTTPRoute {
+		resolvers = append(resolvers, httpRouteHosts{r.Client})
+	}
+	// the Ingress API is served by every cluster
+	return append(resolvers, ingressHosts{r.Client}), nil
+}
+
+// getHost returns the host of cp-console or account-iam from the first
+// resolver which has the object of that name
+func (r *AccountIAMReconciler) getHost(ctx context.Context, instance *operatorv1alpha1.AccountIAM, name string) (string, error) {
+	resolvers, err := r.hostResolvers(instance)
+	if err != nil {
+		return "", err
+	}
+
+	var notFound error
+	for _, resolver := range resolvers {
+		host, err := resolver.host(ctx, name, instance.Namespace)
+		if err == nil {
+			klog.Infof("%s host from %s: %s", name, resolver.provider(), host)
+			return host, nil
+		}
+		if !k8serrors.IsNotFound(err) {
+			klog.Errorf("Failed to get the host of %s from %s in namespace %s: %v", name, resolver.provider(), instance.Namespace, err)
+			return "", err
+		}
+		if notFound == nil {
+			notFound = err
+		}
+	}
+	klog.Errorf("Failed to get the host of %s in namespace %s: %v", name, instance.Namespace, notFound)
+	return "", notFound
+}
+
+// endpointURL returns the URL set in the spec without its trailing slash, or
+// else the https URL of the host of the object with the name
+func (r *AccountIAMReconciler) endpointURL(ctx context.Context, instance *operatorv1alpha1.AccountIAM, specURL, name string) (string, error) {
+	if specURL != "" {
+		return strings.TrimSuffix(specURL, "/"), nil
+	}
+	host, err := r.getHost(ctx, instance, name)
+	if err != nil {
+		return "", err
+	}
+	return "https://" + host, nil
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	ocproute "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+var _ = Describe("Host resolution", func() {
+	ctx := context.Background()
+
+	var r *AccountIAMReconciler
+	var instance *operatorv1alpha1.AccountIAM
+
+	// each test has its own namespace, so that the objects of one backend do not show to another
+	newNamespace := func(name string) string {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, namespace))).To(Succeed())
+		return name
+	}
+
+	createRoute := func(ns, name, host string) {
+		route := &ocproute.Route{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Spec: ocproute.RouteSpec{
+				Host: host,
+				To:   ocproute.RouteTargetReference{Kind: "Service", Name: name},
+			},
+		}
+		Expect(k8sClient.Create(ctx, route)).To(Succeed())
+	}
+
+	createHTTPRoute := func(ns, name, host string) {
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(schema.FromAPIVersionAndKind(resources.HTTPRouteAPIGroupVersion, resources.HTTPRouteKind))
+		route.SetName(name)
+		route.SetNamespace(ns)
+		Expect(unstructured.SetNestedStringSlice(route.Object, []string{host}, "spec", "hostnames")).To(Succeed())
+		Expect(k8sClient.Create(ctx, route)).To(Succeed())
+	}
+
+	createIngress := func(ns, name, host string) {
+		pathType := networkingv1.PathTypePrefix
+		ingress := &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Spec: networkingv1.IngressSpec{
+				Rules: []networkingv1.IngressRule{{
+					Host: host,
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+								Name: name,
+								Port: networkingv1.ServiceBackendPort{Number: 443},
+							}},
+						}},
+					}},
+				}},
+			},
+		}
+		Expect(k8sClient.Create(ctx, ingress)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		r = &AccountIAMReconciler{
+			Client:   k8sClient,
+			Scheme:   k8sClient.Scheme(),
+			Config:   cfg,
+			Recorder: record.NewFakeRecorder(10),
+		}
+		instance = &operatorv1alpha1.AccountIAM{}
+	})
+
+	It("reads the hosts from the Routes", func() {
+		instance.Namespace = newNamespace("hosts-route")
+		instance.Spec.Hosts.Provider = operatorv1alpha1.HostProviderRoute
+		createRoute(instance.Namespace, "cp-console", "cp-console.route.example.com")
+
+		Expect(r.getHost(ctx, instance, "cp-console")).To(Equal("cp-console.route.example.com"))
+	})
+
+	It("reads the hosts from the HTTPRoutes", func() {
+		instance.Namespace = newNamespace("hosts-httproute")
+		instance.Spec.Hosts.Provider = operatorv1alpha1.HostProviderHTTPRoute
+		createHTTPRoute(instance.Namespace, "cp-console", "cp-console.httproute.example.com")
+
+		Expect(r.getHost(ctx, instance, "cp-console")).To(Equal("cp-console.httproute.example.com"))
+	})
+
+	It("reads the hosts from the Ingresses", func() {
+		instance.Namespace = newNamespace("hosts-ingress")
+		instance.Spec.Hosts.Provider = operatorv1alpha1.HostProviderIngress
+		createIngress(instance.Namespace, "cp-console", "cp-console.ingress.example.com")
+
+		Expect(r.getHost(ctx, instance, "cp-console")).To(Equal("cp-console.ingress.example.com"))
+	})
+
+	It("takes the hosts from the spec", func() {
+		instance.Namespace = newNamespace("hosts-spec")
+		instance.Spec.Hosts = operatorv1alpha1.HostsSpec{
+			Provider:       operatorv1alpha1.HostProviderSpec,
+			ConsoleHost:    "cp-console.spec.example.com",
+			AccountIAMHost: "account-iam.spec.example.com",
+		}
+
+		Expect(r.getHost(ctx, instance, "cp-console")).To(Equal("cp-console.spec.example.com"))
+		Expect(r.getHost(ctx, instance, "account-iam")).To(Equal("account-iam.spec.example.com"))
+
+		instance.Spec.Hosts.AccountIAMHost = ""
+		_, err := r.getHost(ctx, instance, "account-iam")
+		Expect(err).To(MatchError(ContainSubstring("spec.hosts.accountIAMHost")))
+	})
+
+	It("does not fall back to another backend than the one in the spec", func() {
+		instance.Namespace = newNamespace("hosts-no-fallback")
+		instance.Spec.Hosts.Provider = operatorv1alpha1.HostProviderRoute
+		createIngress(instance.Namespace, "cp-console", "cp-console.ingress.example.com")
+
+		_, err := r.getHost(ctx, instance, "cp-console")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+
+	It("detects the backend which has the object", func() {
+		instance.Namespace = newNamespace("hosts-detect")
+
+		By("falling back to the Ingresses")
+		createIngress(instance.Namespace, "cp-console", "cp-console.ingress.example.com")
+		Expect(r.getHost(ctx, instance, "cp-console")).To(Equal("cp-console.ingress.example.com"))
+
+		By("preferring the HTTPRoutes to the Ingresses")
+		createHTTPRoute(instance.Namespace, "cp-console", "cp-console.httproute.example.com")
+		Expect(r.getHost(ctx, instance, "cp-console")).To(Equal("cp-console.httproute.example.com"))
+
+		By("preferring the Routes to the others")
+		createRoute(instance.Namespace, "cp-console", "cp-console.route.example.com")
+		Expect(r.getHost(ctx, instance, "cp-console")).To(Equal("cp-console.route.example.com"))
+
+		By("reporting the object missing from every backend")
+		_, err := r.getHost(ctx, instance, "account-iam")
+		Expect(errors.IsNotFound(err)).To(BeTrue())
+	})
+})
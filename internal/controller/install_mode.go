@@ -0,0 +1,125 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	olmapi "github.com/operator-framework/api/pkg/operators/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// InstallMode is the OLM install mode of the operator
+type InstallMode string
+
+const (
+	// InstallModeOwnNamespace watches the namespace of the operator
+	InstallModeOwnNamespace InstallMode = "OwnNamespace"
+	// InstallModeSingleNamespace watches one namespace other than that of the operator
+	InstallModeSingleNamespace InstallMode = "SingleNamespace"
+	// InstallModeMultiNamespace watches a set of namespaces
+	InstallModeMultiNamespace InstallMode = "MultiNamespace"
+	// InstallModeAllNamespaces watches all the namespaces
+	InstallModeAllNamespaces InstallMode = "AllNamespaces"
+)
+
+// serviceAccountNamespaceFile holds the namespace of the pod the operator runs in
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// GetOperatorNamespace returns the namespace the operator is deployed in,
+// from OPERATOR_NAMESPACE or else from its service account. It is empty when
+// the operator runs outside of a cluster.
+func GetOperatorNamespace() string {
+	if ns := os.Getenv("OPERATOR_NAMESPACE"); ns != "" {
+		return ns
+	}
+	ns, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(ns))
+}
+
+// GetWatchNamespaces returns the namespaces the operator reconciles. When the
+// operator is installed by OLM they are the target namespaces of the
+// OperatorGroup in its namespace, otherwise they are read from
+// WATCH_NAMESPACE as a comma separated list. Nil means all namespaces.
+// Without the operator namespace only WATCH_NAMESPACE is read.
+func GetWatchNamespaces(ctx context.Context, reader client.Reader, operatorNs string) ([]string, error) {
+	watchNamespaces := strings.Split(os.Getenv("WATCH_NAMESPACE"), ",")
+	if operatorNs == "" {
+		return normalizeNamespaces(watchNamespaces), nil
+	}
+
+	ogList := &olmapi.OperatorGroupList{}
+	if err := reader.List(ctx, ogList, client.InNamespace(operatorNs)); err != nil {
+		if !meta.IsNoMatchError(err) {
+			return nil, err
+		}
+		klog.Infof("OperatorGroup API not found, watching namespaces from WATCH_NAMESPACE")
+		return normalizeNamespaces(watchNamespaces), nil
+	}
+
+	switch len(ogList.Items) {
+	case 0:
+		klog.Infof("No OperatorGroup in namespace %s, watching namespaces from WATCH_NAMESPACE", operatorNs)
+	case 1:
+		og := ogList.Items[0]
+		// the status is empty until OLM has resolved the target namespaces
+		if len(og.Status.Namespaces) > 0 {
+			klog.Infof("Watching the target namespaces of OperatorGroup %s/%s", og.Namespace, og.Name)
+			watchNamespaces = og.Status.Namespaces
+		}
+	default:
+		return nil, fmt.Errorf("found %d OperatorGroups in namespace %s, expected one", len(ogList.Items), operatorNs)
+	}
+	return normalizeNamespaces(watchNamespaces), nil
+}
+
+// normalizeNamespaces trims and deduplicates the namespaces, leaving out the
+// empty ones. It returns nil, for all namespaces, when none is left, the way
+// OLM marks AllNamespaces with a single empty namespace.
+func normalizeNamespaces(namespaces []string) []string {
+	var result []string
+	for _, ns := range namespaces {
+		ns = strings.TrimSpace(ns)
+		if ns != "" && !slices.Contains(result, ns) {
+			result = append(result, ns)
+		}
+	}
+	return result
+}
+
+// GetInstallMode returns the OLM install mode the watched namespaces correspond to
+func GetInstallMode(operatorNs string, watchNamespaces []string) InstallMode {
+	switch {
+	case len(watchNamespaces) == 0:
+		return InstallModeAllNamespaces
+	case len(watchNamespaces) > 1:
+		return InstallModeMultiNamespace
+	case watchNamespaces[0] == operatorNs:
+		return InstallModeOwnNamespace
+	default:
+		return InstallModeSingleNamespace
+	}
+}
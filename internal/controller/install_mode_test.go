@@ -0,0 +1,149 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	olmapi "github.com/operator-framework/api/pkg/operators/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestGetWatchNamespaces(t *testing.T) {
+	const operatorNs = "operators"
+
+	scheme := runtime.NewScheme()
+	if err := olmapi.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	operatorGroup := func(name string, namespaces ...string) *olmapi.OperatorGroup {
+		return &olmapi.OperatorGroup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: operatorNs},
+			Status:     olmapi.OperatorGroupStatus{Namespaces: namespaces},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		operatorNs     string
+		watchNamespace string
+		operatorGroups []client.Object
+		noOLM          bool
+		namespaces     []string
+		installMode    InstallMode
+		wantErr        bool
+	}{{
+		name:           "OwnNamespace",
+		operatorNs:     operatorNs,
+		operatorGroups: []client.Object{operatorGroup("og", operatorNs)},
+		namespaces:     []string{operatorNs},
+		installMode:    InstallModeOwnNamespace,
+	}, {
+		name:           "SingleNamespace",
+		operatorNs:     operatorNs,
+		operatorGroups: []client.Object{operatorGroup("og", "apps")},
+		namespaces:     []string{"apps"},
+		installMode:    InstallModeSingleNamespace,
+	}, {
+		name:           "MultiNamespace with duplicates",
+		operatorNs:     operatorNs,
+		operatorGroups: []client.Object{operatorGroup("og", "apps", operatorNs, "apps")},
+		namespaces:     []string{"apps", operatorNs},
+		installMode:    InstallModeMultiNamespace,
+	}, {
+		name:           "AllNamespaces",
+		operatorNs:     operatorNs,
+		watchNamespace: "apps",
+		operatorGroups: []client.Object{operatorGroup("og", "")},
+		namespaces:     nil,
+		installMode:    InstallModeAllNamespaces,
+	}, {
+		name:           "OperatorGroup not resolved yet",
+		operatorNs:     operatorNs,
+		watchNamespace: "apps",
+		operatorGroups: []client.Object{operatorGroup("og")},
+		namespaces:     []string{"apps"},
+		installMode:    InstallModeSingleNamespace,
+	}, {
+		name:           "no OperatorGroup",
+		operatorNs:     operatorNs,
+		watchNamespace: " apps , ,other,apps,",
+		namespaces:     []string{"apps", "other"},
+		installMode:    InstallModeMultiNamespace,
+	}, {
+		name:        "no OperatorGroup nor WATCH_NAMESPACE",
+		operatorNs:  operatorNs,
+		namespaces:  nil,
+		installMode: InstallModeAllNamespaces,
+	}, {
+		name:           "OperatorGroup API not installed",
+		operatorNs:     operatorNs,
+		watchNamespace: operatorNs,
+		noOLM:          true,
+		namespaces:     []string{operatorNs},
+		installMode:    InstallModeOwnNamespace,
+	}, {
+		name:           "outside of a cluster",
+		watchNamespace: "apps",
+		operatorGroups: []client.Object{operatorGroup("og", operatorNs)},
+		namespaces:     []string{"apps"},
+		installMode:    InstallModeSingleNamespace,
+	}, {
+		name:           "several OperatorGroups",
+		operatorNs:     operatorNs,
+		operatorGroups: []client.Object{operatorGroup("og", operatorNs), operatorGroup("other", "apps")},
+		wantErr:        true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WATCH_NAMESPACE", tt.watchNamespace)
+			builder := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.operatorGroups...)
+			if tt.noOLM {
+				builder.WithInterceptorFuncs(interceptor.Funcs{
+					List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+						return &meta.NoKindMatchError{GroupKind: olmapi.GroupVersion.WithKind("OperatorGroup").GroupKind()}
+					},
+				})
+			}
+
+			namespaces, err := GetWatchNamespaces(context.Background(), builder.Build(), tt.operatorNs)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("watching %v, expected an error", namespaces)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(namespaces, tt.namespaces) {
+				t.Errorf("watching %v, expected %v", namespaces, tt.namespaces)
+			}
+			if mode := GetInstallMode(tt.operatorNs, namespaces); mode != tt.installMode {
+				t.Errorf("install mode %s, expected %s", mode, tt.installMode)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+var _ = Describe("IM issuer", func() {
+	ctx := context.Background()
+	const ns = "issuer-test"
+	const originalIssuer = "https://cp-console.example.com/oidc/endpoint/OP"
+	const accountIAMIssuer = "https://account-iam.example.com/api/2.0/accounts/global_account/identity_providers/default"
+
+	var r *AccountIAMReconciler
+	var instance *operatorv1alpha1.AccountIAM
+	var bootstrapData *BootstrapSecret
+
+	BeforeEach(func() {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, namespace))).To(Succeed())
+
+		idpconfig := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "platform-auth-idp", Namespace: ns},
+			Data:       map[string]string{"OIDC_ISSUER_URL": originalIssuer},
+		}
+		Expect(k8sClient.Create(ctx, idpconfig)).To(Succeed())
+
+		for _, name := range imDeployments {
+			labels := map[string]string{"app": name}
+			deploy := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+				Spec: appsv1.DeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: name, Image: name}}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, deploy)).To(Succeed())
+		}
+
+		instance = &operatorv1alpha1.AccountIAM{ObjectMeta: metav1.ObjectMeta{Name: "accountiam", Namespace: ns}}
+		bootstrapData = &BootstrapSecret{DefaultIDPValue: base64.StdEncoding.EncodeToString([]byte(accountIAMIssuer))}
+		r = &AccountIAMReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Config: cfg, Recorder: record.NewFakeRecorder(10)}
+	})
+
+	AfterEach(func() {
+		idpconfig := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "platform-auth-idp", Namespace: ns}}
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, idpconfig))).To(Succeed())
+		Expect(k8sClient.DeleteAllOf(ctx, &appsv1.Deployment{}, client.InNamespace(ns))).To(Succeed())
+	})
+
+	// expectIssuer checks the issuer of platform-auth-idp and the one the IM
+	// deployments are rolled out for
+	expectIssuer := func(issuer string) *corev1.ConfigMap {
+		idpconfig := &corev1.ConfigMap{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "platform-auth-idp", Namespace: ns}, idpconfig)).To(Succeed())
+		Expect(idpconfig.Data).To(HaveKeyWithValue("OIDC_ISSUER_URL", issuer))
+		for _, name := range imDeployments {
+			deploy := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: name, Namespace: ns}, deploy)).To(Succeed())
+			Expect(deploy.Spec.Template.Annotations).To(HaveKeyWithValue(resources.IssuerAnnotation, issuer))
+		}
+		return idpconfig
+	}
+
+	It("should record the original issuer and restore it when the issuer is no longer managed", func() {
+		// the rollouts are waited for, the deployments have no controller here
+		Expect(r.updateIssuer(ctx, instance, bootstrapData)).To(BeFalse())
+		idpconfig := expectIssuer(accountIAMIssuer)
+		Expect(idpconfig.Annotations).To(HaveKeyWithValue(resources.OriginalIssuerAnnotation, originalIssuer))
+
+		By("Turning the management of the issuer off")
+		manage := false
+		instance.Spec.ManageIMIssuer = &manage
+		Expect(r.updateIssuer(ctx, instance, bootstrapData)).To(BeTrue())
+		idpconfig = expectIssuer(originalIssuer)
+		Expect(idpconfig.Annotations).NotTo(HaveKey(resources.OriginalIssuerAnnotation))
+
+		By("Leaving the issuer alone from then on")
+		idpconfig.Data["OIDC_ISSUER_URL"] = "https://idp.example.com"
+		Expect(k8sClient.Update(ctx, idpconfig)).To(Succeed())
+		Expect(r.updateIssuer(ctx, instance, bootstrapData)).To(BeTrue())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(idpconfig), idpconfig)).To(Succeed())
+		Expect(idpconfig.Data).To(HaveKeyWithValue("OIDC_ISSUER_URL", "https://idp.example.com"))
+	})
+})
@@ -0,0 +1,93 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	batchv1 "k8s.io/api/batch/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+// reconcileJob runs the job rendered from the manifest once for the given
+// run. A job left from another run is deleted and created again, while a job
+// of the same run is kept as it is, so a failed job stays for inspection
+// until the run changes, its rendered spec changes, or the job is deleted. A
+// completed job is kept even when its spec changes.
+func (r *AccountIAMReconciler) reconcileJob(ctx context.Context, instance *operatorv1alpha1.AccountIAM, manifest string, data TemplateData, run string) (operatorv1alpha1.OperandStatus, error) {
+	object, err := r.renderTemplate(instance, manifest, data)
+	if err != nil {
+		return operatorv1alpha1.OperandStatus{}, err
+	}
+	annotations := object.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[resources.JobRunAnnotation] = run
+	hash, err := manifestHash(object)
+	if err != nil {
+		return operatorv1alpha1.OperandStatus{}, err
+	}
+	annotations[resources.SpecHashAnnotation] = hash
+	object.SetAnnotations(annotations)
+
+	status := operatorv1alpha1.OperandStatus{Name: object.GetName(), Kind: "Job", Status: operatorv1alpha1.OperandNotReady}
+
+	job := &batchv1.Job{}
+	if err := r.Get(ctx, client.ObjectKey{Name: object.GetName(), Namespace: object.GetNamespace()}, job); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return status, err
+		}
+		klog.Infof("Creating job %s", object.GetName())
+		if err := r.Create(ctx, object, client.FieldOwner(fieldOwner)); err != nil && !k8serrors.IsAlreadyExists(err) {
+			return status, err
+		}
+		status.Message = "Job created"
+		return status, nil
+	}
+
+	if job.DeletionTimestamp != nil {
+		status.Message = "Waiting for the job of the previous run to be deleted"
+		return status, nil
+	}
+
+	current := jobStatusOf(job)
+	switch {
+	case job.Annotations[resources.JobRunAnnotation] != run:
+		klog.Infof("Deleting job %s of the previous run", job.Name)
+		status.Message = "Deleting the job of the previous run"
+	case current.Status != operatorv1alpha1.OperandReady && job.Annotations[resources.SpecHashAnnotation] != hash:
+		klog.Infof("Deleting job %s, its spec has changed", job.Name)
+		status.Message = "Deleting the job, its spec has changed"
+	default:
+		return current, nil
+	}
+
+	background := metav1.DeletePropagationBackground
+	if err := r.Delete(ctx, job, &client.DeleteOptions{
+		PropagationPolicy: &background,
+	}); err != nil && !k8serrors.IsNotFound(err) {
+		return status, err
+	}
+	return status, nil
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// appliedObjects counts the rendered objects written to the API server
+	appliedObjects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "accountiam_objects_applied_total",
+		Help: "Number of rendered objects applied, by kind",
+	}, []string{"kind"})
+
+	// skippedObjects counts the rendered objects left as they are, since
+	// their rendered manifest has not changed and the live object still
+	// has its fields
+	skippedObjects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "accountiam_objects_skipped_total",
+		Help: "Number of rendered objects not applied as they were unchanged, by kind",
+	}, []string{"kind"})
+)
+
+func init() {
+	// served on the metrics endpoint of the manager
+	metrics.Registry.MustRegister(appliedObjects, skippedObjects)
+}
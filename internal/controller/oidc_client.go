@@ -0,0 +1,120 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	res "github.com/IBM/ibm-user-management-operator/internal/resources/yamls"
+)
+
+// legacyClientSecret is the OIDC client secret older releases wrote into every bootstrap secret
+const legacyClientSecret = "mcsp-secret"
+
+// replaceLegacyClientSecret replaces the hard-coded client secret of older
+// releases in the bootstrap secret with a generated one, which is then
+// registered with IM
+func (r *AccountIAMReconciler) replaceLegacyClientSecret(ctx context.Context, instance *operatorv1alpha1.AccountIAM, bootstrapsecret *corev1.Secret) error {
+	if instance.Spec.AccountIAM.OIDCClient.SecretName != "" || string(bootstrapsecret.Data["ClientSecret"]) != legacyClientSecret {
+		return nil
+	}
+
+	klog.Infof("Replacing the default OIDC client secret in secret user-mgmt-bootstrap in namespace %s", instance.Namespace)
+	clientSecret, err := generatePassword()
+	if err != nil {
+		return err
+	}
+	bootstrapsecret.Data["ClientSecret"] = clientSecret
+	return r.Update(ctx, bootstrapsecret)
+}
+
+// loadOIDCClient replaces the OIDC client in the bootstrap data with the one
+// from the secret referenced in the spec, if any. It returns false with the
+// condition set when the secret is missing or incomplete.
+func (r *AccountIAMReconciler) loadOIDCClient(ctx context.Context, instance *operatorv1alpha1.AccountIAM, bootstrapData *BootstrapSecret) (bool, error) {
+	name := instance.Spec.AccountIAM.OIDCClient.SecretName
+	if name == "" {
+		return true, nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: instance.Namespace}, secret); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return false, err
+		}
+		oidcClientNotReady(instance, fmt.Sprintf("OIDC client secret %s not found in namespace %s", name, instance.Namespace))
+		return false, nil
+	}
+
+	var missing []string
+	for _, key := range []string{"client_id", "client_secret"} {
+		if len(secret.Data[key]) == 0 {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		oidcClientNotReady(instance, fmt.Sprintf("OIDC client secret %s is missing the keys %s", name, strings.Join(missing, ", ")))
+		return false, nil
+	}
+
+	clientID := base64.StdEncoding.EncodeToString(secret.Data["client_id"])
+	clientSecret := base64.StdEncoding.EncodeToString(secret.Data["client_secret"])
+	r.Redactor.AddSecrets(string(secret.Data["client_secret"]), clientSecret)
+
+	bootstrapData.ClientID = clientID
+	bootstrapData.ClientSecret = clientSecret
+	bootstrapData.DefaultAUDValue = clientID
+	bootstrapData.GlobalAccountAud = clientID
+	return true, nil
+}
+
+// oidcClientNotReady sets the PrereqsSatisfied condition to False with the message
+func oidcClientNotReady(instance *operatorv1alpha1.AccountIAM, message string) {
+	klog.Infof("Waiting for the OIDC client of AccountIAM %s/%s: %s", instance.Namespace, instance.Name, message)
+	setCondition(instance, operatorv1alpha1.ConditionPrereqsSatisfied, metav1.ConditionFalse, operatorv1alpha1.ReasonInvalidOIDCClient, message)
+}
+
+// registerOIDCClient registers the OIDC client of the bootstrap secret with
+// IM, unless the spec references a client which is already registered. The
+// job runs again when the client or the account-iam URL changes.
+func (r *AccountIAMReconciler) registerOIDCClient(ctx context.Context, instance *operatorv1alpha1.AccountIAM, data TemplateData) ([]operatorv1alpha1.OperandStatus, error) {
+	if instance.Spec.AccountIAM.OIDCClient.SecretName != "" {
+		return nil, nil
+	}
+
+	// the run is a hash, so that the client secret does not show in the job
+	sum := sha256.Sum256([]byte(data.ClientID + "\n" + data.ClientSecret + "\n" + data.AccountIAMURL))
+	run := fmt.Sprintf("%x", sum[:8])
+
+	klog.Infof("Registering OIDC client %s with IM", data.ClientID)
+	job, err := r.reconcileJob(ctx, instance, res.REGISTER_OIDC_CLIENT_JOB, data, run)
+	if err != nil {
+		return nil, err
+	}
+	return []operatorv1alpha1.OperandStatus{job}, nil
+}
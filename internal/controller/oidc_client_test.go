@@ -0,0 +1,75 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+)
+
+func TestLoadOIDCClientWaitsForTheSecret(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	instance := &operatorv1alpha1.AccountIAM{
+		ObjectMeta: metav1.ObjectMeta{Name: "accountiam", Namespace: "oidc"},
+		Spec: operatorv1alpha1.AccountIAMSpec{
+			AccountIAM: operatorv1alpha1.AccountIAMAppSpec{
+				OIDCClient: operatorv1alpha1.OIDCClientSpec{SecretName: "account-iam-oidc-client"},
+			},
+		},
+	}
+
+	for name, data := range map[string]map[string][]byte{
+		"missing":    nil,
+		"incomplete": {"client_id": []byte("account-iam")},
+		"complete":   {"client_id": []byte("account-iam"), "client_secret": []byte("s3cr3t-client")},
+	} {
+		builder := fake.NewClientBuilder().WithScheme(scheme)
+		if data != nil {
+			builder.WithObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "account-iam-oidc-client", Namespace: "oidc"},
+				Data:       data,
+			})
+		}
+		r := &AccountIAMReconciler{Client: builder.Build(), Scheme: scheme}
+		instance.Status.Conditions = nil
+
+		ready, err := r.loadOIDCClient(ctx, instance, &BootstrapSecret{})
+		if err != nil {
+			t.Fatalf("%s secret: %v", name, err)
+		}
+		cond := meta.FindStatusCondition(instance.Status.Conditions, operatorv1alpha1.ConditionPrereqsSatisfied)
+		switch {
+		case name == "complete" && (!ready || cond != nil):
+			t.Errorf("%s secret: not loaded, condition %v", name, cond)
+		case name != "complete" && (ready || cond == nil || cond.Reason != operatorv1alpha1.ReasonInvalidOIDCClient):
+			t.Errorf("%s secret: ready %v, condition %v", name, ready, cond)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+// OperandConfig holds the values from the AccountIAM spec, with defaults
+// applied, which are injected into the operand manifests
+type OperandConfig struct {
+	AppImage              string
+	AppReplicas           int32
+	AppResources          string
+	DBName                string
+	DBSchema              string
+	DBUser                string
+	DBHost                string
+	DBPort                int32
+	DBSSLMode             string
+	DBCASecret            string
+	DBSuperuserSecret     string
+	DBBootstrapImage      string
+	DBBootstrapResources  string
+	DBMigrationImage      string
+	DBMigrationVersion    string
+	DBMigrationResources  string
+	BackupSchedule        string
+	BackupImage           string
+	BackupResources       string
+	BackupPVC             string
+	BackupKeep            int32
+	BackupS3Endpoint      string
+	BackupS3Bucket        string
+	BackupS3Prefix        string
+	BackupS3Secret        string
+	BackupS3Image         string
+	BackupRestore         string
+	IMConfigImage         string
+	IMConfigResources     string
+	OIDCRegistrationImage string
+	CertRotationImage     string
+	CertRotationReplicas  int32
+	CertRotationResources string
+}
+
+// TemplateData is the data the operand manifest templates are executed with
+type TemplateData struct {
+	BootstrapSecret
+	OperandConfig
+}
+
+var defaultAppResources = corev1.ResourceRequirements{
+	Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("300m"),
+		corev1.ResourceMemory: resource.MustParse("400Mi"),
+	},
+	Limits: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1500m"),
+		corev1.ResourceMemory: resource.MustParse("800Mi"),
+	},
+}
+
+var defaultMigrationResources = corev1.ResourceRequirements{
+	Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("100m"),
+		corev1.ResourceMemory: resource.MustParse("300Mi"),
+	},
+	Limits: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("500m"),
+		corev1.ResourceMemory: resource.MustParse("600Mi"),
+	},
+}
+
+var defaultCertRotationResources = corev1.ResourceRequirements{
+	Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("2m"),
+		corev1.ResourceMemory: resource.MustParse("32Mi"),
+	},
+	Limits: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("5m"),
+		corev1.ResourceMemory: resource.MustParse("64Mi"),
+	},
+}
+
+// newOperandConfig returns the operand configuration of the instance with defaults applied
+func newOperandConfig(instance *operatorv1alpha1.AccountIAM) (OperandConfig, error) {
+	spec := instance.Spec
+	cfg := OperandConfig{
+		AppImage:              stringOrDefault(spec.AccountIAM.Image, resources.DefaultAccountIAMImage),
+		AppReplicas:           int32OrDefault(spec.AccountIAM.Replicas, resources.DefaultReplicas),
+		DBName:                stringOrDefault(spec.Database.Name, resources.DefaultDBName),
+		DBSchema:              stringOrDefault(spec.Database.Schema, resources.DefaultDBSchema),
+		DBUser:                stringOrDefault(spec.Database.User, resources.DefaultDBUser),
+		DBBootstrapImage:      stringOrDefault(spec.Database.Bootstrap.Image, resources.DefaultMCSPUtilsImage),
+		IMConfigImage:         stringOrDefault(spec.IMConfig.Image, resources.DefaultIMConfigImage),
+		OIDCRegistrationImage: resources.DefaultMCSPUtilsImage,
+		CertRotationImage:     stringOrDefault(spec.CertRotation.Image, resources.DefaultCertRotationImage),
+		CertRotationReplicas:  int32OrDefault(spec.CertRotation.Replicas, resources.DefaultReplicas),
+	}
+	// the migration runs from the application image so they stay in step
+	cfg.DBMigrationImage = stringOrDefault(spec.Database.Migration.Image, cfg.AppImage)
+	cfg.DBMigrationVersion = spec.Database.Migration.Version
+	if external := spec.Database.External; external != nil {
+		cfg.DBHost = external.Host
+		cfg.DBPort = int32OrDefault(external.Port, resources.DefaultDBPort)
+		cfg.DBCASecret = external.CASecret
+		cfg.DBSSLMode = stringOrDefault(external.SSLMode, resources.DefaultExternalDBSSLMode)
+		if external.SSLMode == "" && external.CASecret != "" {
+			cfg.DBSSLMode = resources.VerifiedDBSSLMode
+		}
+	} else {
+		// the database is on the EDB Cluster found by the last reconcile,
+		// whose server certificate names its read-write service
+		cluster := instance.Status.Database.Cluster
+		cfg.DBHost = stringOrDefault(cluster.RWService, resources.DefaultDBCluster+"-rw")
+		cfg.DBPort = resources.DefaultDBPort
+		cfg.DBSuperuserSecret = stringOrDefault(cluster.SuperuserSecret, resources.DefaultDBCluster+"-superuser")
+		cfg.DBCASecret = dbClusterCASecret(instance, cluster)
+		cfg.DBSSLMode = resources.VerifiedDBSSLMode
+	}
+
+	backup := spec.Database.Backup
+	cfg.BackupSchedule = backup.Schedule
+	cfg.BackupRestore = backup.Restore
+	// pg_dump comes with psql in the image of the bootstrap job
+	cfg.BackupImage = stringOrDefault(backup.Image, cfg.DBBootstrapImage)
+	if backup.PVC != nil {
+		cfg.BackupPVC = backup.PVC.ClaimName
+		cfg.BackupKeep = int32OrDefault(backup.PVC.Keep, resources.DefaultBackupKeep)
+	}
+	if backup.S3 != nil {
+		cfg.BackupS3Endpoint = strings.TrimSuffix(backup.S3.Endpoint, "/")
+		cfg.BackupS3Bucket = backup.S3.Bucket
+		if prefix := strings.Trim(backup.S3.Prefix, "/"); prefix != "" {
+			cfg.BackupS3Prefix = prefix + "/"
+		}
+		cfg.BackupS3Secret = backup.S3.CredentialsSecret
+		cfg.BackupS3Image = stringOrDefault(backup.S3.Image, resources.DefaultBackupS3Image)
+	}
+
+	var err error
+	if cfg.AppResources, err = renderResources(spec.AccountIAM.Resources, defaultAppResources); err != nil {
+		return cfg, err
+	}
+	if cfg.DBBootstrapResources, err = renderResources(spec.Database.Bootstrap.Resources, corev1.ResourceRequirements{}); err != nil {
+		return cfg, err
+	}
+	if cfg.DBMigrationResources, err = renderResources(spec.Database.Migration.Resources, defaultMigrationResources); err != nil {
+		return cfg, err
+	}
+	if cfg.BackupResources, err = renderResources(backup.Resources, corev1.ResourceRequirements{}); err != nil {
+		return cfg, err
+	}
+	if cfg.IMConfigResources, err = renderResources(spec.IMConfig.Resources, corev1.ResourceRequirements{}); err != nil {
+		return cfg, err
+	}
+	if cfg.CertRotationResources, err = renderResources(spec.CertRotation.Resources, defaultCertRotationResources); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// renderResources renders the resource requirements as JSON, which the
+// templates embed as YAML flow style
+func renderResources(res *corev1.ResourceRequirements, def corev1.ResourceRequirements) (string, error) {
+	if res == nil {
+		res = &def
+	}
+	out, err := json.Marshal(res)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func stringOrDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+func int32OrDefault(value *int32, def int32) int32 {
+	if value == nil {
+		return def
+	}
+	return *value
+}
+
+// dbTLSEnforced returns true if the sslmode requires TLS and verifies the
+// server with the CA certificate
+func dbTLSEnforced(cfg OperandConfig) bool {
+	return cfg.DBCASecret != "" && (cfg.DBSSLMode == "verify-ca" || cfg.DBSSLMode == "verify-full")
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+// prerequisiteKinds returns the kinds the operators account-iam depends on
+// serve. EDB is not needed with an external database.
+func prerequisiteKinds(instance *operatorv1alpha1.AccountIAM) []schema.GroupVersionKind {
+	kinds := []schema.GroupVersionKind{
+		schema.FromAPIVersionAndKind(resources.WebSphereAPIGroupVersion, resources.WebSphereKind),
+	}
+	if instance.Spec.Database.External == nil {
+		kinds = append([]schema.GroupVersionKind{schema.FromAPIVersionAndKind(resources.EDBAPIGroupVersion, resources.EDBClusterKind)}, kinds...)
+	}
+	return kinds
+}
+
+// checkPrerequisites returns true if the APIs of the prerequisite operators
+// are installed. Otherwise it reports the missing kinds in the condition and
+// in a Warning event, and the reconcile waits for them, either until the CRD
+// watch sees them installed or until the next periodic requeue.
+func (r *AccountIAMReconciler) checkPrerequisites(instance *operatorv1alpha1.AccountIAM) (bool, error) {
+	var missing []string
+	for _, gvk := range prerequisiteKinds(instance) {
+		exist, err := r.CheckCRD(gvk.GroupVersion().String(), gvk.Kind)
+		if err != nil {
+			return false, err
+		}
+		if !exist {
+			missing = append(missing, gvk.String())
+		}
+	}
+	if len(missing) == 0 {
+		return true, nil
+	}
+
+	message := "Waiting for the APIs of the prerequisite operators: " + strings.Join(missing, "; ")
+	klog.Infof("AccountIAM %s/%s: %s", instance.Namespace, instance.Name, message)
+
+	// the event is recorded once, when the missing kinds change
+	cond := meta.FindStatusCondition(instance.Status.Conditions, operatorv1alpha1.ConditionPrereqsSatisfied)
+	if cond == nil || cond.Reason != operatorv1alpha1.ReasonPrerequisitesMissing || cond.Message != message {
+		r.recordEvent(instance, corev1.EventTypeWarning, operatorv1alpha1.ReasonPrerequisitesMissing, "%s", message)
+	}
+	setCondition(instance, operatorv1alpha1.ConditionPrereqsSatisfied, metav1.ConditionFalse, operatorv1alpha1.ReasonPrerequisitesMissing, message)
+	return false, nil
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// redactedValue replaces the secret values
+const redactedValue = "<redacted>"
+
+// minSecretLength is the length under which values are not redacted, as
+// they would mask unrelated text
+const minSecretLength = 6
+
+// maxSecrets bounds the secret values a Redactor keeps. The values added
+// least recently, such as the passwords rotated out long ago, are dropped
+// first.
+const maxSecrets = 256
+
+// Redactor replaces the secret values it knows of in text. It implements
+// klog.LogFilter, so once installed with klog.SetLogFilter it applies to all
+// klog output. A nil Redactor leaves text as it is.
+type Redactor struct {
+	mu sync.RWMutex
+	// secrets are the values to redact, in the order they were last added
+	secrets  []string
+	replacer *strings.Replacer
+}
+
+// NewRedactor returns a Redactor without any secret values
+func NewRedactor() *Redactor {
+	return &Redactor{}
+}
+
+// AddSecrets adds the values to redact, or marks them as added last when
+// they are known already
+func (r *Redactor) AddSecrets(values ...string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	added := false
+	for _, value := range values {
+		if len(value) < minSecretLength {
+			continue
+		}
+		if i := slices.Index(r.secrets, value); i >= 0 {
+			r.secrets = append(slices.Delete(r.secrets, i, i+1), value)
+			continue
+		}
+		r.secrets = append(r.secrets, value)
+		added = true
+	}
+	if len(r.secrets) > maxSecrets {
+		r.secrets = slices.Delete(r.secrets, 0, len(r.secrets)-maxSecrets)
+		added = true
+	}
+	if !added {
+		return
+	}
+
+	// the longest values go first, so that a value containing another is redacted whole
+	secrets := slices.Clone(r.secrets)
+	sort.Slice(secrets, func(i, j int) bool {
+		return len(secrets[i]) > len(secrets[j])
+	})
+	oldnew := make([]string, 0, 2*len(secrets))
+	for _, secret := range secrets {
+		oldnew = append(oldnew, secret, redactedValue)
+	}
+	r.replacer = strings.NewReplacer(oldnew...)
+}
+
+// Redact returns the text with the secret values replaced
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.replacer == nil {
+		return text
+	}
+	return r.replacer.Replace(text)
+}
+
+// RedactError returns the error with the secret values replaced in its
+// message, which still unwraps to the error
+func (r *Redactor) RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	message := r.Redact(err.Error())
+	if message == err.Error() {
+		return err
+	}
+	return &redactedError{message: message, err: err}
+}
+
+// redactedError is an error whose message has been redacted
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e *redactedError) Error() string { return e.message }
+
+func (e *redactedError) Unwrap() error { return e.err }
+
+// Filter redacts the arguments of klog.Info and the like
+func (r *Redactor) Filter(args []interface{}) []interface{} {
+	return r.redactArgs(args)
+}
+
+// FilterF redacts the formatted message of klog.Infof and the like
+func (r *Redactor) FilterF(format string, args []interface{}) (string, []interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if redacted := r.Redact(message); redacted != message {
+		return "%s", []interface{}{redacted}
+	}
+	return format, args
+}
+
+// FilterS redacts the message and the values of klog.InfoS and the like
+func (r *Redactor) FilterS(msg string, keysAndValues []interface{}) (string, []interface{}) {
+	return r.Redact(msg), r.redactArgs(keysAndValues)
+}
+
+// redactArgs replaces the arguments which contain secret values by their redacted text
+func (r *Redactor) redactArgs(args []interface{}) []interface{} {
+	var result []interface{}
+	for i, arg := range args {
+		text := fmt.Sprint(arg)
+		redacted := r.Redact(text)
+		if redacted == text {
+			continue
+		}
+		if result == nil {
+			result = append([]interface{}{}, args...)
+		}
+		result[i] = redacted
+	}
+	if result == nil {
+		return args
+	}
+	return result
+}
+
+// secretValues returns the values of the fields of the bootstrap data tagged secret, which are set
+func secretValues(data BootstrapSecret) []string {
+	var values []string
+	val := reflect.ValueOf(data)
+	for i := 0; i < val.NumField(); i++ {
+		if val.Type().Field(i).Tag.Get("secret") == "true" && val.Field(i).String() != "" {
+			values = append(values, val.Field(i).String())
+		}
+	}
+	return values
+}
+
+// recordEvent records an event on the object with the secret values redacted from its message
+func (r *AccountIAMReconciler) recordEvent(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Recorder.Event(object, eventtype, reason, r.Redactor.Redact(fmt.Sprintf(messageFmt, args...)))
+}
@@ -0,0 +1,180 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	res "github.com/IBM/ibm-user-management-operator/internal/resources/yamls"
+)
+
+func TestSecretsAreRedactedFromLogs(t *testing.T) {
+	ctx := context.Background()
+	host := "cp-console.example.com"
+
+	// Capture the klog output through the redactor
+	var logs bytes.Buffer
+	redactor := NewRedactor()
+	klog.LogToStderr(false)
+	klog.SetOutput(&logs)
+	klog.SetLogFilter(redactor)
+	defer func() {
+		klog.SetLogFilter(nil)
+		klog.LogToStderr(true)
+	}()
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := operatorv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	instance := &operatorv1alpha1.AccountIAM{
+		ObjectMeta: metav1.ObjectMeta{Name: "accountiam", Namespace: "redact", UID: "accountiam-uid"},
+	}
+	recorder := record.NewFakeRecorder(100)
+	r := &AccountIAMReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance).
+			WithInterceptorFuncs(interceptor.Funcs{Patch: applyAsCreateOrUpdate}).Build(),
+		Scheme:   scheme,
+		Recorder: recorder,
+		Redactor: redactor,
+	}
+
+	// Create the bootstrap secret and the secrets rendered from it
+	bootstrapData := &BootstrapSecret{}
+	if err := r.loadBootstrapData(ctx, instance, "https://"+host, bootstrapData); err != nil {
+		t.Fatal(err)
+	}
+	operandConfig, err := newOperandConfig(instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.InjectData(ctx, instance, res.APP_SECRETS, TemplateData{*bootstrapData, operandConfig}); err != nil {
+		t.Fatal(err)
+	}
+
+	decodedData, err := r.decodeData(*bootstrapData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decodedData.PGPassword == "" || decodedData.ClientSecret == "" {
+		t.Fatalf("secret values missing from the bootstrap data")
+	}
+	secrets := append(secretValues(*bootstrapData), secretValues(decodedData)...)
+
+	// Log the secrets in every way the controller does
+	klog.Infof("cp-console route host: %s", host)
+	for _, secret := range secrets {
+		klog.Infof("value: %s", secret)
+		klog.Info("value: ", secret)
+		klog.Infoln("value:", secret)
+		klog.InfoS("value", "secret", secret)
+		klog.Errorf("Failed to update secret: %v", fmt.Errorf("invalid value %q", secret))
+		r.recordEvent(instance, corev1.EventTypeNormal, "Value", "value: %s", secret)
+	}
+	klog.Flush()
+	close(recorder.Events)
+
+	var events []string
+	for event := range recorder.Events {
+		events = append(events, event)
+	}
+	output := logs.String() + strings.Join(events, "\n")
+
+	for _, secret := range secrets {
+		if strings.Contains(output, secret) {
+			t.Errorf("secret value %q found in the logs and events:\n%s", secret, output)
+		}
+	}
+	if !strings.Contains(output, host) {
+		t.Errorf("value %q, which is not secret, was redacted from the logs:\n%s", host, output)
+	}
+}
+
+// applyAsCreateOrUpdate stands in for server-side apply, which the fake
+// client does not support, with a create or an update of the object
+func applyAsCreateOrUpdate(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if patch != client.Apply {
+		return c.Patch(ctx, obj, patch, opts...)
+	}
+	if err := c.Create(ctx, obj.DeepCopyObject().(client.Object)); !k8serrors.IsAlreadyExists(err) {
+		return err
+	}
+	return c.Update(ctx, obj)
+}
+
+func TestFailedConditionsAndErrorsAreRedacted(t *testing.T) {
+	secret := "s3cr3t-password"
+	redactor := NewRedactor()
+	redactor.AddSecrets(secret)
+	r := &AccountIAMReconciler{Redactor: redactor}
+
+	instance := &operatorv1alpha1.AccountIAM{}
+	stepErr := fmt.Errorf("failed to connect with password %s: %w", secret, context.DeadlineExceeded)
+	r.markFailed(instance, operatorv1alpha1.ConditionDatabaseReady, stepErr)
+	if message := instance.Status.Conditions[0].Message; strings.Contains(message, secret) {
+		t.Errorf("secret value found in the condition message %q", message)
+	}
+
+	err := redactor.RedactError(stepErr)
+	if strings.Contains(err.Error(), secret) {
+		t.Errorf("secret value found in the error %q", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("redacted error %q does not unwrap to the error", err)
+	}
+}
+
+func TestRedactorDropsTheSecretsAddedLeastRecently(t *testing.T) {
+	redactor := NewRedactor()
+	current := "current-password"
+	redactor.AddSecrets(current, "rotated-password-0")
+	for i := 1; i < maxSecrets; i++ {
+		redactor.AddSecrets(fmt.Sprintf("rotated-password-%d", i))
+		// the values in use are added again by every reconcile
+		redactor.AddSecrets(current)
+	}
+
+	if redacted := redactor.Redact(current); redacted != redactedValue {
+		t.Errorf("value in use is no longer redacted: %q", redacted)
+	}
+	if redacted := redactor.Redact("rotated-password-0"); redacted != "rotated-password-0" {
+		t.Errorf("value added least recently is still redacted: %q", redacted)
+	}
+	if redacted := redactor.Redact("rotated-password-1"); redacted != redactedValue {
+		t.Errorf("value added recently is no longer redacted: %q", redacted)
+	}
+}
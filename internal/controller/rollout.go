@@ -0,0 +1,101 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+// imDeployments are the IM deployments which read OIDC_ISSUER_URL from
+// platform-auth-idp at startup
+var imDeployments = []string{"platform-auth-service", "platform-identity-provider"}
+
+// rolloutIssuer restarts the pods of the deployment with a rollout when
+// they were not started with the issuer, by setting the issuer in an
+// annotation of its pod template. The rollout replaces every replica under
+// the strategy of the deployment, and a rollout interrupted by a restart of
+// the operator is resumed as the annotation is already set.
+func (r *AccountIAMReconciler) rolloutIssuer(ctx context.Context, ns, name, issuer string) error {
+	deploy := &appsv1.Deployment{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: ns}, deploy); err != nil {
+		return err
+	}
+	if deploy.Spec.Template.Annotations[resources.IssuerAnnotation] == issuer {
+		return nil
+	}
+
+	klog.Infof("Rolling out deployment %s in namespace %s for OIDC_ISSUER_URL %s", name, ns, issuer)
+	patch := client.MergeFrom(deploy.DeepCopy())
+	if deploy.Spec.Template.Annotations == nil {
+		deploy.Spec.Template.Annotations = map[string]string{}
+	}
+	deploy.Spec.Template.Annotations[resources.IssuerAnnotation] = issuer
+	if err := r.Patch(ctx, deploy, patch); err != nil {
+		klog.Errorf("Failed to patch deployment %s in namespace %s: %v", name, ns, err)
+		return err
+	}
+	return nil
+}
+
+// rolloutStatus returns the status of the rollout of the deployment, in the
+// terms of kubectl rollout status: Ready once every replica is updated and
+// available and no old replica is left, Failed once the rollout has exceeded
+// its progress deadline
+func (r *AccountIAMReconciler) rolloutStatus(ctx context.Context, ns, name string) (operatorv1alpha1.OperandStatus, error) {
+	status := operatorv1alpha1.OperandStatus{Name: name, Kind: "Deployment", Status: operatorv1alpha1.OperandNotReady}
+
+	deploy := &appsv1.Deployment{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: ns}, deploy); err != nil {
+		return status, err
+	}
+	if deploy.Status.ObservedGeneration < deploy.Generation {
+		status.Message = "Waiting for the rollout to be observed"
+		return status, nil
+	}
+	for _, cond := range deploy.Status.Conditions {
+		if cond.Type == appsv1.DeploymentProgressing && cond.Status == corev1.ConditionFalse && cond.Reason == "ProgressDeadlineExceeded" {
+			status.Status = operatorv1alpha1.OperandFailed
+			status.Message = cond.Message
+			return status, nil
+		}
+	}
+
+	replicas := int32(1)
+	if deploy.Spec.Replicas != nil {
+		replicas = *deploy.Spec.Replicas
+	}
+	switch {
+	case deploy.Status.UpdatedReplicas < replicas:
+		status.Message = fmt.Sprintf("%d of %d replicas updated", deploy.Status.UpdatedReplicas, replicas)
+	case deploy.Status.Replicas > deploy.Status.UpdatedReplicas:
+		status.Message = fmt.Sprintf("%d old replicas pending termination", deploy.Status.Replicas-deploy.Status.UpdatedReplicas)
+	case deploy.Status.AvailableReplicas < deploy.Status.UpdatedReplicas:
+		status.Message = fmt.Sprintf("%d of %d updated replicas available", deploy.Status.AvailableReplicas, deploy.Status.UpdatedReplicas)
+	default:
+		status.Status = operatorv1alpha1.OperandReady
+	}
+	return status, nil
+}
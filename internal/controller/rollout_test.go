@@ -0,0 +1,108 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+var _ = Describe("IM rollout", func() {
+	ctx := context.Background()
+	const ns = "rollout-test"
+	const issuer = "https://account-iam.example.com/api/2.0/accounts/global_account/identity_providers/default"
+
+	var r *AccountIAMReconciler
+	var deploy *appsv1.Deployment
+
+	BeforeEach(func() {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, namespace))).To(Succeed())
+
+		replicas := int32(3)
+		labels := map[string]string{"app": "platform-auth-service"}
+		deploy = &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "platform-auth-service", Namespace: ns},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "auth", Image: "auth"}}},
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, deploy)).To(Succeed())
+
+		r = &AccountIAMReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Config: cfg}
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, deploy))).To(Succeed())
+	})
+
+	// setRolloutStatus sets the status of the deployment as its controller would
+	setRolloutStatus := func(replicas, updated, available int32) {
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(deploy), deploy)).To(Succeed())
+		deploy.Status.ObservedGeneration = deploy.Generation
+		deploy.Status.Replicas = replicas
+		deploy.Status.UpdatedReplicas = updated
+		deploy.Status.AvailableReplicas = available
+		Expect(k8sClient.Status().Update(ctx, deploy)).To(Succeed())
+	}
+
+	It("should roll the deployment out once for the issuer", func() {
+		Expect(r.rolloutIssuer(ctx, ns, deploy.Name, issuer)).To(Succeed())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(deploy), deploy)).To(Succeed())
+		Expect(deploy.Spec.Template.Annotations).To(HaveKeyWithValue(resources.IssuerAnnotation, issuer))
+		generation := deploy.Generation
+
+		By("Keeping the pod template for the same issuer")
+		Expect(r.rolloutIssuer(ctx, ns, deploy.Name, issuer)).To(Succeed())
+		Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(deploy), deploy)).To(Succeed())
+		Expect(deploy.Generation).To(Equal(generation))
+	})
+
+	It("should report the rollout until every replica is replaced", func() {
+		Expect(r.rolloutIssuer(ctx, ns, deploy.Name, issuer)).To(Succeed())
+
+		setRolloutStatus(4, 1, 3)
+		status, err := r.rolloutStatus(ctx, ns, deploy.Name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Status).To(Equal(operatorv1alpha1.OperandNotReady))
+		Expect(status.Message).To(Equal("1 of 3 replicas updated"))
+
+		setRolloutStatus(4, 3, 3)
+		status, err = r.rolloutStatus(ctx, ns, deploy.Name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Message).To(Equal("1 old replicas pending termination"))
+
+		setRolloutStatus(3, 3, 3)
+		status, err = r.rolloutStatus(ctx, ns, deploy.Name)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.Status).To(Equal(operatorv1alpha1.OperandReady))
+	})
+})
@@ -0,0 +1,125 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+	res "github.com/IBM/ibm-user-management-operator/internal/resources/yamls"
+)
+
+// rotateDBPassword rotates the password of the database user when a new
+// rotation is requested in the spec. The new password is kept pending in the
+// bootstrap secret while a job changes it in the database, and then replaces
+// the current one, so that it is rendered into account-iam-database-secret
+// and account-iam rolls to pick it up. If the job fails, the rotation is
+// rolled back by discarding the pending password, and the current one stays
+// in use until another rotation is requested.
+func (r *AccountIAMReconciler) rotateDBPassword(ctx context.Context, instance *operatorv1alpha1.AccountIAM, bootstrapData *BootstrapSecret, operandConfig OperandConfig) error {
+	dbStatus := &instance.Status.Database
+	rotation := instance.Spec.Database.PasswordRotation
+	if rotation == "" || rotation == dbStatus.PasswordRotation {
+		return nil
+	}
+
+	bootstrapsecret := &corev1.Secret{}
+	if err := r.Get(ctx, client.ObjectKey{Name: "user-mgmt-bootstrap", Namespace: instance.Namespace}, bootstrapsecret); err != nil {
+		return err
+	}
+
+	if len(bootstrapsecret.Data["PGPasswordPending"]) == 0 {
+		// a failed rotation has already been rolled back, do not retry it
+		failed, err := r.rotationFailed(ctx, instance.Namespace, rotation)
+		if err != nil || failed {
+			return err
+		}
+
+		klog.Infof("Rotating the password of database user %s", operandConfig.DBUser)
+		pgPassword, err := generatePassword()
+		if err != nil {
+			return err
+		}
+		r.Redactor.AddSecrets(string(pgPassword), base64.StdEncoding.EncodeToString(pgPassword))
+		bootstrapsecret.Data["PGPasswordPending"] = pgPassword
+		if err := r.Update(ctx, bootstrapsecret); err != nil {
+			return err
+		}
+	}
+
+	job, err := r.reconcileJob(ctx, instance, res.DB_ROTATE_PASSWORD_JOB, TemplateData{*bootstrapData, operandConfig}, rotation)
+	if err != nil {
+		return err
+	}
+	setOperandStatus(instance, job)
+
+	switch job.Status {
+	case operatorv1alpha1.OperandNotReady:
+		setCondition(instance, operatorv1alpha1.ConditionCredentialsRotated, metav1.ConditionFalse, operatorv1alpha1.ReasonInProgress, "Waiting for Job "+job.Name)
+		return nil
+	case operatorv1alpha1.OperandFailed:
+		klog.Errorf("Failed to rotate the password of database user %s, rolling back: %s", operandConfig.DBUser, job.Message)
+		delete(bootstrapsecret.Data, "PGPasswordPending")
+		if err := r.Update(ctx, bootstrapsecret); err != nil {
+			return err
+		}
+		r.recordEvent(instance, corev1.EventTypeWarning, "PasswordRotationFailed", "Failed to rotate the password of database user %s, kept the current password: %s", operandConfig.DBUser, job.Message)
+		setCondition(instance, operatorv1alpha1.ConditionCredentialsRotated, metav1.ConditionFalse, operatorv1alpha1.ReasonOperandFailed, job.Message)
+		return nil
+	}
+
+	// The database has the new password, make it the current one
+	pgPassword := bootstrapsecret.Data["PGPasswordPending"]
+	bootstrapsecret.Data["PGPassword"] = pgPassword
+	delete(bootstrapsecret.Data, "PGPasswordPending")
+	if err := r.Update(ctx, bootstrapsecret); err != nil {
+		return err
+	}
+	bootstrapData.PGPassword = base64.StdEncoding.EncodeToString(pgPassword)
+	bootstrapData.PGPasswordPending = ""
+
+	now := metav1.Now()
+	dbStatus.PasswordRotation = rotation
+	dbStatus.PasswordRotationTime = &now
+	r.recordEvent(instance, corev1.EventTypeNormal, "PasswordRotated", "Rotated the password of database user %s", operandConfig.DBUser)
+	setCondition(instance, operatorv1alpha1.ConditionCredentialsRotated, metav1.ConditionTrue, operatorv1alpha1.ReasonSucceeded, "")
+	return nil
+}
+
+// rotationFailed returns true if the rotation job of the given rotation has failed
+func (r *AccountIAMReconciler) rotationFailed(ctx context.Context, ns, rotation string) (bool, error) {
+	job := &batchv1.Job{}
+	if err := r.Get(ctx, client.ObjectKey{Name: "rotate-account-iam-db-password", Namespace: ns}, job); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if job.Annotations[resources.JobRunAnnotation] != rotation {
+		return false, nil
+	}
+	return jobStatusOf(job).Status == operatorv1alpha1.OperandFailed, nil
+}
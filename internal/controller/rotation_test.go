@@ -0,0 +1,172 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+)
+
+var _ = Describe("Database password rotation", func() {
+	ctx := context.Background()
+	const ns = "rotation-test"
+	const currentPassword = "current-password"
+
+	var r *AccountIAMReconciler
+	var recorder *record.FakeRecorder
+	var instance *operatorv1alpha1.AccountIAM
+	var bootstrapData *BootstrapSecret
+	var operandConfig OperandConfig
+
+	BeforeEach(func() {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: ns}}
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(ctx, namespace))).To(Succeed())
+
+		instance = &operatorv1alpha1.AccountIAM{
+			ObjectMeta: metav1.ObjectMeta{Name: "accountiam", Namespace: ns},
+			Spec: operatorv1alpha1.AccountIAMSpec{
+				Database: operatorv1alpha1.DatabaseSpec{PasswordRotation: "rotation-1"},
+			},
+		}
+		Expect(k8sClient.Create(ctx, instance)).To(Succeed())
+
+		bootstrapsecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "user-mgmt-bootstrap", Namespace: ns},
+			Data:       map[string][]byte{"PGPassword": []byte(currentPassword)},
+		}
+		Expect(k8sClient.Create(ctx, bootstrapsecret)).To(Succeed())
+
+		var err error
+		operandConfig, err = newOperandConfig(instance)
+		Expect(err).NotTo(HaveOccurred())
+		bootstrapData = &BootstrapSecret{PGPassword: base64.StdEncoding.EncodeToString([]byte(currentPassword))}
+		recorder = record.NewFakeRecorder(10)
+		r = &AccountIAMReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Config: cfg, Recorder: recorder}
+	})
+
+	AfterEach(func() {
+		Expect(client.IgnoreNotFound(k8sClient.Delete(ctx, instance))).To(Succeed())
+		Expect(k8sClient.DeleteAllOf(ctx, &corev1.Secret{}, client.InNamespace(ns))).To(Succeed())
+		Expect(k8sClient.DeleteAllOf(ctx, &batchv1.Job{}, client.InNamespace(ns))).To(Succeed())
+	})
+
+	getBootstrapSecret := func() map[string][]byte {
+		bootstrapsecret := &corev1.Secret{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "user-mgmt-bootstrap", Namespace: ns}, bootstrapsecret)).To(Succeed())
+		return bootstrapsecret.Data
+	}
+
+	getRotationJob := func() *batchv1.Job {
+		job := &batchv1.Job{}
+		Expect(k8sClient.Get(ctx, client.ObjectKey{Name: "rotate-account-iam-db-password", Namespace: ns}, job)).To(Succeed())
+		return job
+	}
+
+	// finishRotationJob sets the status of the rotation job as its
+	// controller would once it has finished with the condition
+	finishRotationJob := func(condType batchv1.JobConditionType) {
+		job := getRotationJob()
+		now := metav1.Now()
+		start := metav1.NewTime(now.Add(-time.Minute))
+		job.Status.StartTime = &start
+		job.Status.Conditions = []batchv1.JobCondition{{
+			Type:               condType,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: now,
+			Message:            "psql exited with 2",
+		}}
+		if condType == batchv1.JobComplete {
+			job.Status.CompletionTime = &now
+		}
+		Expect(k8sClient.Status().Update(ctx, job)).To(Succeed())
+	}
+
+	expectRotatedCondition := func(status metav1.ConditionStatus, reason string) {
+		cond := meta.FindStatusCondition(instance.Status.Conditions, operatorv1alpha1.ConditionCredentialsRotated)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(status))
+		Expect(cond.Reason).To(Equal(reason))
+	}
+
+	It("should keep the pending password across restarts and promote it once the job completes", func() {
+		Expect(r.rotateDBPassword(ctx, instance, bootstrapData, operandConfig)).To(Succeed())
+		pending := getBootstrapSecret()["PGPasswordPending"]
+		Expect(pending).NotTo(BeEmpty())
+		Expect(getBootstrapSecret()["PGPassword"]).To(Equal([]byte(currentPassword)))
+		expectRotatedCondition(metav1.ConditionFalse, operatorv1alpha1.ReasonInProgress)
+		uid := getRotationJob().UID
+
+		By("Resuming the rotation in progress after a restart of the operator")
+		r = &AccountIAMReconciler{Client: k8sClient, Scheme: k8sClient.Scheme(), Config: cfg, Recorder: recorder}
+		bootstrapData = &BootstrapSecret{PGPassword: base64.StdEncoding.EncodeToString([]byte(currentPassword))}
+		Expect(r.rotateDBPassword(ctx, instance, bootstrapData, operandConfig)).To(Succeed())
+		Expect(getBootstrapSecret()["PGPasswordPending"]).To(Equal(pending))
+		Expect(getRotationJob().UID).To(Equal(uid))
+
+		By("Promoting the pending password once the job has completed")
+		finishRotationJob(batchv1.JobComplete)
+		Expect(r.rotateDBPassword(ctx, instance, bootstrapData, operandConfig)).To(Succeed())
+		data := getBootstrapSecret()
+		Expect(data["PGPassword"]).To(Equal(pending))
+		Expect(data).NotTo(HaveKey("PGPasswordPending"))
+		Expect(bootstrapData.PGPassword).To(Equal(base64.StdEncoding.EncodeToString(pending)))
+		Expect(instance.Status.Database.PasswordRotation).To(Equal("rotation-1"))
+		Expect(instance.Status.Database.PasswordRotationTime).NotTo(BeNil())
+		expectRotatedCondition(metav1.ConditionTrue, operatorv1alpha1.ReasonSucceeded)
+
+		By("Leaving the password alone once the rotation is done")
+		Expect(r.rotateDBPassword(ctx, instance, bootstrapData, operandConfig)).To(Succeed())
+		Expect(getBootstrapSecret()).To(Equal(data))
+	})
+
+	It("should roll back a failed rotation and not retry it", func() {
+		Expect(r.rotateDBPassword(ctx, instance, bootstrapData, operandConfig)).To(Succeed())
+		Expect(getBootstrapSecret()["PGPasswordPending"]).NotTo(BeEmpty())
+
+		finishRotationJob(batchv1.JobFailed)
+		Expect(r.rotateDBPassword(ctx, instance, bootstrapData, operandConfig)).To(Succeed())
+		data := getBootstrapSecret()
+		Expect(data).NotTo(HaveKey("PGPasswordPending"))
+		Expect(data["PGPassword"]).To(Equal([]byte(currentPassword)))
+		Expect(instance.Status.Database.PasswordRotation).To(BeEmpty())
+		expectRotatedCondition(metav1.ConditionFalse, operatorv1alpha1.ReasonOperandFailed)
+		Expect(recorder.Events).To(Receive(ContainSubstring("PasswordRotationFailed")))
+		uid := getRotationJob().UID
+
+		By("Not retrying the failed rotation")
+		Expect(r.rotateDBPassword(ctx, instance, bootstrapData, operandConfig)).To(Succeed())
+		Expect(getBootstrapSecret()).NotTo(HaveKey("PGPasswordPending"))
+		Expect(getRotationJob().UID).To(Equal(uid))
+
+		By("Rotating again once another rotation is requested")
+		instance.Spec.Database.PasswordRotation = "rotation-2"
+		Expect(r.rotateDBPassword(ctx, instance, bootstrapData, operandConfig)).To(Succeed())
+		Expect(getBootstrapSecret()["PGPasswordPending"]).NotTo(BeEmpty())
+	})
+})
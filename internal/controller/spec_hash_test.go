@@ -0,0 +1,125 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	res "github.com/IBM/ibm-user-management-operator/internal/resources/yamls"
+)
+
+func TestUnchangedObjectsAreNotWritten(t *testing.T) {
+	ctx := context.Background()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := operatorv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	instance := &operatorv1alpha1.AccountIAM{
+		ObjectMeta: metav1.ObjectMeta{Name: "accountiam", Namespace: "spec-hash", UID: "accountiam-uid"},
+	}
+
+	// the writes the reconciler sends
+	writes := 0
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				writes++
+				// the fake client keeps the stringData of a Secret, which
+				// the API server writes to its data
+				if u, ok := obj.(*unstructured.Unstructured); ok {
+					stringData, _, _ := unstructured.NestedStringMap(u.Object, "stringData")
+					for key, value := range stringData {
+						if err := unstructured.SetNestedField(u.Object, base64.StdEncoding.EncodeToString([]byte(value)), "data", key); err != nil {
+							return err
+						}
+					}
+					unstructured.RemoveNestedField(u.Object, "stringData")
+				}
+				return applyAsCreateOrUpdate(ctx, c, obj, patch, opts...)
+			},
+		}).Build()
+	r := &AccountIAMReconciler{Client: c, Scheme: scheme, Recorder: record.NewFakeRecorder(10)}
+
+	bootstrapData := &BootstrapSecret{}
+	if err := r.loadBootstrapData(ctx, instance, "https://cp-console.example.com", bootstrapData); err != nil {
+		t.Fatal(err)
+	}
+	operandConfig, err := newOperandConfig(instance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifests := append(append([]string{}, res.APP_SECRETS...), res.APP_CONFIGS...)
+	inject := func() {
+		t.Helper()
+		writes = 0
+		if err := r.InjectData(ctx, instance, manifests, TemplateData{*bootstrapData, operandConfig}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	inject()
+	if writes != len(manifests) {
+		t.Fatalf("%d writes to create the %d objects", writes, len(manifests))
+	}
+
+	skipped := testutil.ToFloat64(skippedObjects.WithLabelValues("ConfigMap"))
+	inject()
+	if writes != 0 {
+		t.Errorf("%d writes to reconcile the unchanged objects, expected none", writes)
+	}
+	if testutil.ToFloat64(skippedObjects.WithLabelValues("ConfigMap")) != skipped+1 {
+		t.Errorf("unchanged ConfigMap not counted as skipped")
+	}
+
+	// a rendered field changed on the live object is reverted
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Name: "account-iam", Namespace: "spec-hash"}, cm); err != nil {
+		t.Fatal(err)
+	}
+	rendered := cm.Data["jwt.suffix.issuer"]
+	cm.Data["jwt.suffix.issuer"] = "edited"
+	if err := c.Update(ctx, cm); err != nil {
+		t.Fatal(err)
+	}
+	inject()
+	if writes != 1 {
+		t.Errorf("%d writes to revert the edited ConfigMap, expected 1", writes)
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(cm), cm); err != nil {
+		t.Fatal(err)
+	}
+	if cm.Data["jwt.suffix.issuer"] != rendered {
+		t.Errorf("edited field is %q, expected the rendered %q", cm.Data["jwt.suffix.issuer"], rendered)
+	}
+}
@@ -0,0 +1,235 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	"github.com/IBM/ibm-user-management-operator/internal/resources"
+)
+
+// setCondition sets a condition on the instance for its current generation
+func setCondition(instance *operatorv1alpha1.AccountIAM, condType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               condType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: instance.Generation,
+	})
+}
+
+// markFailed sets the condition to False with the error of the step, with
+// the secret values redacted as the status is readable by any user of the
+// instance, unless the step has already recorded a more specific reason for
+// the same error
+func (r *AccountIAMReconciler) markFailed(instance *operatorv1alpha1.AccountIAM, condType string, err error) {
+	message := r.Redactor.Redact(err.Error())
+	cond := meta.FindStatusCondition(instance.Status.Conditions, condType)
+	if cond != nil && cond.Status == metav1.ConditionFalse && cond.Message == message {
+		return
+	}
+	setCondition(instance, condType, metav1.ConditionFalse, operatorv1alpha1.ReasonFailed, message)
+}
+
+// setOperandStatus records the status of an operand, replacing its previous entry
+func setOperandStatus(instance *operatorv1alpha1.AccountIAM, status operatorv1alpha1.OperandStatus) {
+	for i, operand := range instance.Status.Operands {
+		if operand.Kind == status.Kind && operand.Name == status.Name {
+			instance.Status.Operands[i] = status
+			return
+		}
+	}
+	instance.Status.Operands = append(instance.Status.Operands, status)
+}
+
+// setOperandsCondition records the operand statuses and sets the condition
+// from them: True when all of them are ready, False otherwise
+func setOperandsCondition(instance *operatorv1alpha1.AccountIAM, condType string, statuses ...operatorv1alpha1.OperandStatus) {
+	var failed, pending []string
+	for _, status := range statuses {
+		setOperandStatus(instance, status)
+		switch status.Status {
+		case operatorv1alpha1.OperandFailed:
+			failed = append(failed, fmt.Sprintf("%s %s: %s", status.Kind, status.Name, status.Message))
+		case operatorv1alpha1.OperandNotReady:
+			pending = append(pending, fmt.Sprintf("%s %s", status.Kind, status.Name))
+		}
+	}
+
+	switch {
+	case len(failed) > 0:
+		setCondition(instance, condType, metav1.ConditionFalse, operatorv1alpha1.ReasonOperandFailed, strings.Join(failed, "; "))
+	case len(pending) > 0:
+		setCondition(instance, condType, metav1.ConditionFalse, operatorv1alpha1.ReasonInProgress, "Waiting for "+strings.Join(pending, ", "))
+	default:
+		setCondition(instance, condType, metav1.ConditionTrue, operatorv1alpha1.ReasonSucceeded, "")
+	}
+}
+
+// updateReadyCondition sets the Ready condition and the phase from the
+// conditions of the reconcile steps
+func updateReadyCondition(instance *operatorv1alpha1.AccountIAM) {
+	var notReady []string
+	failed := false
+	for _, condType := range []string{
+		operatorv1alpha1.ConditionPrereqsSatisfied,
+		operatorv1alpha1.ConditionDatabaseReady,
+		operatorv1alpha1.ConditionOperandReady,
+		operatorv1alpha1.ConditionIMIntegrated,
+	} {
+		cond := meta.FindStatusCondition(instance.Status.Conditions, condType)
+		if cond != nil && cond.Status == metav1.ConditionTrue {
+			continue
+		}
+		notReady = append(notReady, condType)
+		if cond != nil && (cond.Reason == operatorv1alpha1.ReasonFailed || cond.Reason == operatorv1alpha1.ReasonOperandFailed) {
+			failed = true
+		}
+	}
+
+	switch {
+	case len(notReady) == 0:
+		setCondition(instance, operatorv1alpha1.ConditionReady, metav1.ConditionTrue, operatorv1alpha1.ReasonSucceeded, "")
+		instance.Status.Phase = operatorv1alpha1.PhaseRunning
+		return
+	case failed:
+		setCondition(instance, operatorv1alpha1.ConditionReady, metav1.ConditionFalse, operatorv1alpha1.ReasonFailed, "Not ready: "+strings.Join(notReady, ", "))
+		instance.Status.Phase = operatorv1alpha1.PhaseFailed
+		return
+	}
+
+	setCondition(instance, operatorv1alpha1.ConditionReady, metav1.ConditionFalse, operatorv1alpha1.ReasonInProgress, "Not ready: "+strings.Join(notReady, ", "))
+	if meta.IsStatusConditionTrue(instance.Status.Conditions, operatorv1alpha1.ConditionPrereqsSatisfied) {
+		instance.Status.Phase = operatorv1alpha1.PhaseInstalling
+	} else {
+		instance.Status.Phase = operatorv1alpha1.PhasePending
+	}
+}
+
+// updateStatus patches the status of the instance when it differs from the original
+func (r *AccountIAMReconciler) updateStatus(ctx context.Context, instance, original *operatorv1alpha1.AccountIAM) error {
+	instance.Status.ObservedGeneration = instance.Generation
+	updateReadyCondition(instance)
+
+	if equality.Semantic.DeepEqual(original.Status, instance.Status) {
+		return nil
+	}
+	if err := r.Client.Status().Patch(ctx, instance, client.MergeFrom(original)); err != nil {
+		klog.Errorf("Failed to update status of AccountIAM %s/%s: %v", instance.Namespace, instance.Name, err)
+		return err
+	}
+	return nil
+}
+
+// jobStatusOf returns the status of the job from its conditions
+func jobStatusOf(job *batchv1.Job) operatorv1alpha1.OperandStatus {
+	status := operatorv1alpha1.OperandStatus{Name: job.Name, Kind: "Job", Status: operatorv1alpha1.OperandNotReady}
+
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			status.Status = operatorv1alpha1.OperandReady
+			return status
+		case batchv1.JobFailed:
+			status.Status = operatorv1alpha1.OperandFailed
+			status.Message = cond.Message
+			return status
+		}
+	}
+	status.Message = fmt.Sprintf("Job is running, %d active and %d failed pods", job.Status.Active, job.Status.Failed)
+	return status
+}
+
+// deploymentStatus returns the status of the deployment: Ready once all its replicas are available
+func (r *AccountIAMReconciler) deploymentStatus(ctx context.Context, ns, name string) (operatorv1alpha1.OperandStatus, error) {
+	status := operatorv1alpha1.OperandStatus{Name: name, Kind: "Deployment", Status: operatorv1alpha1.OperandNotReady}
+
+	deploy := &appsv1.Deployment{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: ns}, deploy); err != nil {
+		if k8serrors.IsNotFound(err) {
+			status.Message = "Deployment not found"
+			return status, nil
+		}
+		return status, err
+	}
+
+	replicas := int32(1)
+	if deploy.Spec.Replicas != nil {
+		replicas = *deploy.Spec.Replicas
+	}
+	if deploy.Status.ObservedGeneration >= deploy.Generation && deploy.Status.AvailableReplicas >= replicas {
+		status.Status = operatorv1alpha1.OperandReady
+		return status, nil
+	}
+	status.Message = fmt.Sprintf("%d of %d replicas available", deploy.Status.AvailableReplicas, replicas)
+	return status, nil
+}
+
+// libertyAppStatus returns the status of the WebSphereLibertyApplication from its Ready condition
+func (r *AccountIAMReconciler) libertyAppStatus(ctx context.Context, ns, name string) (operatorv1alpha1.OperandStatus, error) {
+	status := operatorv1alpha1.OperandStatus{Name: name, Kind: resources.WebSphereKind, Status: operatorv1alpha1.OperandNotReady}
+
+	app := &unstructured.Unstructured{}
+	app.SetGroupVersionKind(schema.FromAPIVersionAndKind(resources.WebSphereAPIGroupVersion, resources.WebSphereKind))
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: ns}, app); err != nil {
+		if k8serrors.IsNotFound(err) {
+			status.Message = resources.WebSphereKind + " not found"
+			return status, nil
+		}
+		return status, err
+	}
+
+	conditions, _, err := unstructured.NestedSlice(app.Object, "status", "conditions")
+	if err != nil {
+		return status, err
+	}
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != "Ready" {
+			continue
+		}
+		if cond["status"] == string(metav1.ConditionTrue) {
+			status.Status = operatorv1alpha1.OperandReady
+			return status, nil
+		}
+		if message, ok := cond["message"].(string); ok {
+			status.Message = message
+		}
+		return status, nil
+	}
+	status.Message = "Waiting for the Ready condition"
+	return status, nil
+}
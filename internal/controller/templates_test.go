@@ -0,0 +1,193 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	wlapi "github.com/WASdev/websphere-liberty-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+	res "github.com/IBM/ibm-user-management-operator/internal/resources/yamls"
+)
+
+// awkward are values which break a manifest unless they are quoted
+const (
+	awkwardUser   = "iam: user #1"
+	awkwardIssuer = "https://idp.example.com/oidc?realm=a&b=c #default"
+	awkwardSecret = `"s3cr3t" {x}: [y], *z`
+)
+
+func TestTemplatesRenderValidManifests(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := wlapi.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := operatorv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	r := &AccountIAMReconciler{Scheme: scheme}
+
+	keep := int32(3)
+	port := int32(15432)
+	instances := map[string]operatorv1alpha1.AccountIAMSpec{
+		"EDB cluster with backups on a PVC": {
+			Database: operatorv1alpha1.DatabaseSpec{
+				Name:   "0123",
+				Schema: "yes",
+				User:   awkwardUser,
+				Backup: operatorv1alpha1.BackupSpec{
+					Schedule: "0 2 * * *",
+					PVC:      &operatorv1alpha1.BackupPVCSpec{ClaimName: "account-iam-backup", Keep: &keep},
+					Restore:  "account-iam-db-backup-29000000",
+				},
+			},
+		},
+		"external database with backups on S3": {
+			Database: operatorv1alpha1.DatabaseSpec{
+				User: awkwardUser,
+				External: &operatorv1alpha1.ExternalDatabaseSpec{
+					Host:              "postgres.example.com",
+					Port:              &port,
+					CredentialsSecret: "account-iam-db-credentials",
+					CASecret:          "account-iam-db-ca",
+				},
+				Backup: operatorv1alpha1.BackupSpec{
+					Schedule: "@daily",
+					S3: &operatorv1alpha1.BackupS3Spec{
+						Endpoint:          "https://s3.example.com",
+						Bucket:            "backups",
+						Prefix:            "account iam #1",
+						CredentialsSecret: "account-iam-s3",
+					},
+					Restore: "account-iam-db-backup-29000000",
+				},
+			},
+		},
+	}
+
+	encode := func(value string) string {
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	bootstrapData := BootstrapSecret{
+		Realm:               encode("PrimaryRealm: #1"),
+		ClientID:            encode("account-iam"),
+		ClientSecret:        encode(awkwardSecret),
+		DiscoveryEndpoint:   encode(awkwardIssuer),
+		PGPassword:          encode(awkwardSecret),
+		DefaultAUDValue:     encode("true"),
+		DefaultIDPValue:     encode(awkwardIssuer),
+		DefaultRealmValue:   encode("PrimaryRealm: #1"),
+		SREMCSPGroupsToken:  encode(awkwardSecret),
+		GlobalRealmValue:    encode("PrimaryRealm"),
+		GlobalAccountIDP:    encode(awkwardIssuer),
+		GlobalAccountAud:    encode("null"),
+		IAMHOSTURL:          encode("https://cp-console.example.com"),
+		AccountIAMURL:       encode("https://account-iam.example.com"),
+		AccountIAMNamespace: encode("ibm-common-services"),
+	}
+	decodedData, err := r.decodeData(bootstrapData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secrets := map[string]bool{}
+	for _, manifest := range res.APP_SECRETS {
+		secrets[manifest] = true
+	}
+
+	for name, spec := range instances {
+		instance := &operatorv1alpha1.AccountIAM{
+			ObjectMeta: metav1.ObjectMeta{Name: "accountiam", Namespace: "ibm-common-services", UID: "accountiam-uid"},
+			Spec:       spec,
+		}
+		operandConfig, err := newOperandConfig(instance)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, manifest := range res.Templates() {
+			// the secrets are rendered with the encoded bootstrap data
+			data := TemplateData{decodedData, operandConfig}
+			if secrets[manifest] {
+				data.BootstrapSecret = bootstrapData
+			}
+
+			object, err := r.renderTemplate(instance, manifest, data)
+			if err != nil {
+				t.Fatalf("%s: failed to render the manifest: %v\n%s", name, err, manifest)
+			}
+
+			// check the manifest against the schema of its kind, rejecting
+			// the fields the kind does not have and the values of the wrong
+			// type
+			typed, err := scheme.New(object.GroupVersionKind())
+			if err != nil {
+				t.Fatalf("%s: %s %s: %v", name, object.GetKind(), object.GetName(), err)
+			}
+			rendered, err := json.Marshal(object.Object)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoder := json.NewDecoder(bytes.NewReader(rendered))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(typed); err != nil {
+				t.Errorf("%s: %s %s does not match its schema: %v\n%s", name, object.GetKind(), object.GetName(), err, rendered)
+				continue
+			}
+
+			// the values are rendered as they are
+			switch object.GetKind() + "/" + object.GetName() {
+			case "Secret/account-iam-database-secret":
+				secret := typed.(*corev1.Secret)
+				expectValue(t, name, "pg_db_user", secret.StringData["pg_db_user"], awkwardUser)
+				expectValue(t, name, "pgPassword", string(secret.Data["pgPassword"]), awkwardSecret)
+			case "Secret/account-iam-mpconfig-secrets":
+				secret := typed.(*corev1.Secret)
+				expectValue(t, name, "DEFAULT_AUD_VALUE", string(secret.Data["DEFAULT_AUD_VALUE"]), "true")
+				expectValue(t, name, "DEFAULT_IDP_VALUE", string(secret.Data["DEFAULT_IDP_VALUE"]), awkwardIssuer)
+			case "ConfigMap/account-iam":
+				configMap := typed.(*corev1.ConfigMap)
+				expectValue(t, name, "jwt.suffix.issuer", configMap.Data["jwt.suffix.issuer"], awkwardIssuer)
+			}
+		}
+	}
+}
+
+func TestRenderTemplateOfUnknownManifest(t *testing.T) {
+	r := &AccountIAMReconciler{Scheme: runtime.NewScheme()}
+	instance := &operatorv1alpha1.AccountIAM{ObjectMeta: metav1.ObjectMeta{Name: "accountiam", Namespace: "ibm-common-services"}}
+	if _, err := r.renderTemplate(instance, "kind: ConfigMap\nname: {{ .Broken", TemplateData{}); err == nil {
+		t.Errorf("rendered a manifest which is not parsed at startup")
+	}
+}
+
+func expectValue(t *testing.T, instance, key, value, expected string) {
+	t.Helper()
+	if value != expected {
+		t.Errorf("%s: %s is %q, expected %q", instance, key, value, expected)
+	}
+}
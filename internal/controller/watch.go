@@ -0,0 +1,179 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	operatorv1alpha1 "github.com/IBM/ibm-user-management-operator/api/v1alpha1"
+)
+
+// watchOptional starts watching a kind whose API is installed by another
+// operator. Watching it from SetupWithManager would stop the manager from
+// starting while the API is missing, so it is watched once the API is found.
+func (r *AccountIAMReconciler) watchOptional(gvk schema.GroupVersionKind, eventHandler handler.EventHandler, predicates ...predicate.Predicate) error {
+	if r.controller == nil {
+		return nil
+	}
+	if _, loaded := r.watches.LoadOrStore(gvk, struct{}{}); loaded {
+		return nil
+	}
+
+	klog.Infof("Watching %s", gvk)
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	if err := r.controller.Watch(source.Kind(r.cache, client.Object(obj), eventHandler, predicates...)); err != nil {
+		r.watches.Delete(gvk)
+		return err
+	}
+	return nil
+}
+
+// ownedByAccountIAM enqueues the AccountIAM which controls the object
+func (r *AccountIAMReconciler) ownedByAccountIAM() handler.EventHandler {
+	return handler.EnqueueRequestForOwner(r.Scheme, r.restMapper, &operatorv1alpha1.AccountIAM{}, handler.OnlyControllerOwner())
+}
+
+// accountIAMsInNamespace enqueues the AccountIAMs in the namespace of the
+// object, for the shared resources they read but do not own
+func (r *AccountIAMReconciler) accountIAMsInNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := &operatorv1alpha1.AccountIAMList{}
+	if err := r.List(ctx, list, client.InNamespace(obj.GetNamespace())); err != nil {
+		klog.Errorf("Failed to list AccountIAM in namespace %s: %v", obj.GetNamespace(), err)
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(list.Items))
+	for _, item := range list.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: item.Name, Namespace: item.Namespace},
+		})
+	}
+	return requests
+}
+
+// referencedSecretsField indexes the AccountIAMs by the secrets in their spec
+const referencedSecretsField = ".spec.referencedSecrets"
+
+// indexReferencedSecrets returns the secrets the spec of the AccountIAM
+// references: the secret of the OIDC client, the CA secret of the database,
+// the credentials and CA secrets of the external database, and the
+// credentials secret of the object store of the backups
+func indexReferencedSecrets(obj client.Object) []string {
+	spec := obj.(*operatorv1alpha1.AccountIAM).Spec
+	var names []string
+	if spec.AccountIAM.OIDCClient.SecretName != "" {
+		names = append(names, spec.AccountIAM.OIDCClient.SecretName)
+	}
+	if spec.Database.CASecret != "" {
+		names = append(names, spec.Database.CASecret)
+	}
+	if external := spec.Database.External; external != nil {
+		if external.CredentialsSecret != "" {
+			names = append(names, external.CredentialsSecret)
+		}
+		if external.CASecret != "" {
+			names = append(names, external.CASecret)
+		}
+	}
+	if s3 := spec.Database.Backup.S3; s3 != nil && s3.CredentialsSecret != "" {
+		names = append(names, s3.CredentialsSecret)
+	}
+	return names
+}
+
+// accountIAMsForReferencedSecret enqueues the AccountIAMs which reference the
+// secret in their spec
+func (r *AccountIAMReconciler) accountIAMsForReferencedSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := &operatorv1alpha1.AccountIAMList{}
+	if err := r.List(ctx, list, client.InNamespace(obj.GetNamespace()), client.MatchingFields{referencedSecretsField: obj.GetName()}); err != nil {
+		klog.Errorf("Failed to list AccountIAM in namespace %s: %v", obj.GetNamespace(), err)
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(list.Items))
+	for _, item := range list.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: item.Name, Namespace: item.Namespace},
+		})
+	}
+	return requests
+}
+
+// withName filters the events to the objects with one of the names
+func withName(names ...string) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return slices.Contains(names, obj.GetName())
+	})
+}
+
+// withLabel filters the events to the objects with the label
+func withLabel(key string) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		_, ok := obj.GetLabels()[key]
+		return ok
+	})
+}
+
+// optionalCRDs are the CRDs of the optional APIs the operator checks for
+var optionalCRDs = []string{
+	"clusters.postgresql.k8s.enterprisedb.io",
+	"webspherelibertyapplications.liberty.websphere.ibm.com",
+	"routes.route.openshift.io",
+	"httproutes.gateway.networking.k8s.io",
+}
+
+// crdMetadata returns the object to watch the metadata of the CRDs with, as
+// the apiextensions types are not in the scheme
+func crdMetadata() client.Object {
+	crd := &metav1.PartialObjectMetadata{}
+	crd.SetGroupVersionKind(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"})
+	return crd
+}
+
+// optionalCRDChanged invalidates the discovery, so that the next CRD check
+// sees the API of the CRD installed or removed, and enqueues all the
+// AccountIAMs, so that those waiting for the API proceed
+func (r *AccountIAMReconciler) optionalCRDChanged(ctx context.Context, obj client.Object) []reconcile.Request {
+	klog.Infof("CRD %s changed, refreshing the API discovery", obj.GetName())
+	r.invalidateDiscovery()
+
+	list := &operatorv1alpha1.AccountIAMList{}
+	if err := r.List(ctx, list); err != nil {
+		klog.Errorf("Failed to list AccountIAM: %v", err)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(list.Items))
+	for _, item := range list.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: item.Name, Namespace: item.Namespace},
+		})
+	}
+	return requests
+}
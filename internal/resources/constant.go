@@ -25,4 +25,68 @@ const (
 	WebSphereAPIGroupVersion = "liberty.websphere.ibm.com/v1"
 	// WebSphereKind is the kind of WebSphereLibertyApplication
 	WebSphereKind = "WebSphereLibertyApplication"
+	// RouteAPIGroupVersion is the api group version of Route
+	RouteAPIGroupVersion = "route.openshift.io/v1"
+	// RouteKind is the kind of Route
+	RouteKind = "Route"
+	// HTTPRouteAPIGroupVersion is the api group version of HTTPRoute
+	HTTPRouteAPIGroupVersion = "gateway.networking.k8s.io/v1"
+	// HTTPRouteKind is the kind of HTTPRoute
+	HTTPRouteKind = "HTTPRoute"
+
+	// DefaultAccountIAMImage is the default image of account-iam and its DB migration job
+	DefaultAccountIAMImage = "docker-na-public.artifactory.swg-devops.com/hyc-cloud-private-scratch-docker-local/ibmcom/account-iam-amd64:20240722"
+	// DefaultMCSPUtilsImage is the default image of the DB bootstrap job
+	DefaultMCSPUtilsImage = "docker-na-public.artifactory.swg-devops.com/hyc-cloud-private-integration-docker-local/ibmcom/mcsp-utils:latest"
+	// DefaultIMConfigImage is the default image of the IM config job
+	DefaultIMConfigImage = "docker-na-public.artifactory.swg-devops.com/hyc-cloud-private-scratch-docker-local/ibmcom/mcsp-im-config-job-amd64:f2a2456"
+	// DefaultBackupS3Image is the default image of the MinIO client which transfers the DB backups
+	DefaultBackupS3Image = "quay.io/minio/mc:RELEASE.2024-06-12T14-34-03Z"
+	// DefaultCertRotationImage is the default image of the iam-cert-rotation-manager
+	DefaultCertRotationImage = "icr.io/automation-saas-platform/access-management/iam-cert-rotation:20240306103454-main-86f22aa63ce252c4add52c8c7bf11ff24c430764"
+	// DefaultReplicas is the default number of replicas of the account-iam and cert rotation deployments
+	DefaultReplicas = 1
+	// DefaultRealm is the default IAM realm
+	DefaultRealm = "PrimaryRealm"
+	// DefaultClientID is the default OIDC client ID
+	DefaultClientID = "mcsp-id"
+	// DefaultDBName is the default name of the account-iam database
+	DefaultDBName = "account_iam"
+	// DefaultDBSchema is the default schema of the account-iam database
+	DefaultDBSchema = "accountiam"
+	// DefaultDBUser is the default user of the account-iam database
+	DefaultDBUser = "user_accountiam"
+	// DefaultDBCluster is the EDB Cluster of Common Services
+	DefaultDBCluster = "common-service-db"
+	// DefaultDBPort is the default port of PostgreSQL
+	DefaultDBPort = 5432
+	// DefaultExternalDBSSLMode is the default sslmode of the connection to an
+	// external database without a CA secret
+	DefaultExternalDBSSLMode = "require"
+	// VerifiedDBSSLMode is the sslmode of the connection to a database whose
+	// CA certificate is known
+	VerifiedDBSSLMode = "verify-full"
+	// DefaultBackupKeep is the default number of DB backups kept on a PVC
+	DefaultBackupKeep = 7
+	// DBBackupLabel labels the jobs of the DB backup CronJob
+	DBBackupLabel = "operator.ibm.com/db-backup"
+	// JobRunAnnotation identifies the run of a job, the job is recreated when it changes
+	JobRunAnnotation = "operator.ibm.com/job-run"
+	// SpecHashAnnotation records the hash of the rendered manifest of an
+	// object, the object is written again, or recreated for a job, only when
+	// it changes
+	SpecHashAnnotation = "operator.ibm.com/spec-hash"
+	// RestoreReplicasAnnotation records the replicas of account-iam while it
+	// is scaled down for a restore of the database
+	RestoreReplicasAnnotation = "operator.ibm.com/restore-replicas"
+	// Finalizer is set on the AccountIAM to tear down what owner references do not cover
+	Finalizer = "operator.ibm.com/accountiam-cleanup"
+	// IssuerAnnotation records on the pod template of the IM deployments the
+	// OIDC_ISSUER_URL they were rolled out for
+	IssuerAnnotation = "operator.ibm.com/oidc-issuer-url"
+	// BootstrapOverridesMigratedAnnotation marks the bootstrap secret whose
+	// user-set values have been copied into the overrides secret
+	BootstrapOverridesMigratedAnnotation = "operator.ibm.com/bootstrap-overrides-migrated"
+	// OriginalIssuerAnnotation records on platform-auth-idp the OIDC_ISSUER_URL it had before account-iam
+	OriginalIssuerAnnotation = "operator.ibm.com/original-oidc-issuer-url"
 )
@@ -9,14 +9,15 @@ var APP_SECRETS = []string{
 
 var APP_CONFIGS = []string{
 	CONFIG_JWT,
+	EGRESS,
 }
 
 var APP_STATIC_YAMLS = []string{
 	INGRESS,
-	EGRESS,
 	CONFIG_ENV,
-	DB_MIGRATION_MCSPID_SA,
-	DB_MIGRATION_MCSPID,
+}
+
+var APP_WORKLOADS = []string{
 	ACCOUNT_IAM_APP,
 }
 
@@ -34,10 +35,10 @@ metadata:
   annotations:
     argocd.argoproj.io/sync-wave: "0"
 data:
-  realm: {{ .Realm }}
-  client_id: {{ .ClientID }}
-  client_secret: {{ .ClientSecret }}
-  discovery_endpoint: {{ .DiscoveryEndpoint }}
+  realm: {{ .Realm | quote }}
+  client_id: {{ .ClientID | quote }}
+  client_secret: {{ .ClientSecret | quote }}
+  discovery_endpoint: {{ .DiscoveryEndpoint | quote }}
 type: Opaque
 `
 var OKD_Auth = `
@@ -53,7 +54,7 @@ metadata:
   annotations:
     argocd.argoproj.io/sync-wave: "0"
 data:
-  user_validation_api_v2: {{ .UserValidationAPIV2 }}
+  user_validation_api_v2: {{ .UserValidationAPIV2 | quote }}
 type: Opaque
 `
 
@@ -70,17 +71,25 @@ metadata:
   annotations:
     argocd.argoproj.io/sync-wave: "0"
 stringData:
-  pg_jdbc_host: common-service-db-rw
-  pg_jdbc_port: "5432"
-  pg_db_name: account_iam
-  pg_db_schema: accountiam
-  pg_db_user: user_accountiam
+  pg_jdbc_host: {{ .DBHost | quote }}
+  pg_jdbc_port: {{ .DBPort | quote }}
+  pg_db_name: {{ .DBName | quote }}
+  pg_db_schema: {{ .DBSchema | quote }}
+  pg_db_user: {{ .DBUser | quote }}
   pg_jdbc_password_jndi: "jdbc/iamdatasource"
+{{- if .DBSSLMode }}
+  pg_jdbc_ssl_mode: {{ .DBSSLMode | quote }}
+  PGSSLMODE: {{ .DBSSLMode | quote }}
+{{- end }}
+{{- if .DBCASecret }}
+  pg_jdbc_ssl_root_cert: /config/db-ca/ca.crt
+  PGSSLROOTCERT: /config/db-ca/ca.crt
+{{- end }}
 data:
-  pgPassword: {{ .PGPassword }}
-  GLOBAL_ACCOUNT_AUD: {{ .GlobalAccountAud }}
-  GLOBAL_ACCOUNT_IDP: {{ .GlobalAccountIDP }}
-  GLOBAL_ACCOUNT_REALM: {{ .GlobalRealmValue }}
+  pgPassword: {{ .PGPassword | quote }}
+  GLOBAL_ACCOUNT_AUD: {{ .GlobalAccountAud | quote }}
+  GLOBAL_ACCOUNT_IDP: {{ .GlobalAccountIDP | quote }}
+  GLOBAL_ACCOUNT_REALM: {{ .GlobalRealmValue | quote }}
 type: Opaque
 `
 
@@ -97,10 +106,10 @@ metadata:
   annotations:
     argocd.argoproj.io/sync-wave: "0"
 data:
-  DEFAULT_AUD_VALUE: {{ .DefaultAUDValue }}
-  DEFAULT_IDP_VALUE: {{ .DefaultIDPValue }}
-  DEFAULT_REALM_VALUE: {{ .DefaultRealmValue }}
-  SRE_MCSP_GROUPS_TOKEN: {{ .SREMCSPGroupsToken }}
+  DEFAULT_AUD_VALUE: {{ .DefaultAUDValue | quote }}
+  DEFAULT_IDP_VALUE: {{ .DefaultIDPValue | quote }}
+  DEFAULT_REALM_VALUE: {{ .DefaultRealmValue | quote }}
+  SRE_MCSP_GROUPS_TOKEN: {{ .SREMCSPGroupsToken | quote }}
 type: Opaque
 `
 
@@ -169,7 +178,7 @@ spec:
           endPort: 65535
         # Port for PG DB
         - protocol: TCP
-          port: 5432
+          port: {{ .DBPort }}
 `
 
 const CONFIG_ENV = `
@@ -202,7 +211,7 @@ metadata:
   annotations:
     argocd.argoproj.io/sync-wave: "0"
 data:
-  jwt.suffix.issuer: {{ .DefaultIDPValue }}
+  jwt.suffix.issuer: {{ .DefaultIDPValue | quote }}
 `
 
 const DB_MIGRATION_MCSPID = `
@@ -231,7 +240,7 @@ spec:
       restartPolicy: Never
       containers:
         - name: dbmigrate
-          image: docker-na-public.artifactory.swg-devops.com/hyc-cloud-private-scratch-docker-local/ibmcom/account-iam-amd64:20240722
+          image: {{ .DBMigrationImage | quote }}
           envFrom:
             - secretRef:
                 name: account-iam-database-secret
@@ -241,14 +250,13 @@ spec:
           args:
             - '/dbmigration/run.sh'
           volumeMounts:
+{{- if .DBCASecret }}
+            - name: db-ca
+              readOnly: true
+              mountPath: /config/db-ca
+{{- end }}
           imagePullPolicy: Always
-          resources:
-            requests:
-              cpu: 100m
-              memory: 300Mi
-            limits:
-              cpu: 500m
-              memory: 600Mi
+          resources: {{ .DBMigrationResources }}
       serviceAccountName: account-iam-migration
       volumes:
         - name: account-iam-token
@@ -259,6 +267,15 @@ spec:
                   expirationSeconds: 7200
                   path: account-iam-token
             defaultMode: 420
+{{- if .DBCASecret }}
+        - name: db-ca
+          secret:
+            secretName: {{ .DBCASecret | quote }}
+            items:
+            - key: ca.crt
+              path: ca.crt
+            defaultMode: 420
+{{- end }}
 `
 
 const DB_MIGRATION_MCSPID_SA = `
@@ -304,9 +321,13 @@ spec:
   manageTLS: true
   networkPolicy:
     disable: true
-  applicationImage: docker-na-public.artifactory.swg-devops.com/hyc-cloud-private-scratch-docker-local/ibmcom/account-iam-amd64:20240722
+  applicationImage: {{ .AppImage | quote }}
   pullPolicy: Always
-  replicas: 
+  replicas: {{ .AppReplicas }}
+  deployment:
+    annotations:
+      operator.ibm.com/bootstrap-hash: {{ .BootstrapHash | quote }}
+      operator.ibm.com/db-ssl-mode: {{ .DBSSLMode | quote }}
   probes:
     startup:
       httpGet:
@@ -336,13 +357,7 @@ spec:
     port:  9445
   expose: true
   createKnativeService: false
-  resources:
-    requests:
-      cpu: 300m
-      memory: 400Mi
-    limits:
-      cpu: 1500m
-      memory: 800Mi
+  resources: {{ .AppResources }}
   volumes:
     - name: account-iam-token
       projected:
@@ -372,6 +387,15 @@ spec:
               name: account-iam-database-secret
           - secret:
               name: account-iam-mpconfig-secrets
+{{- if .DBCASecret }}
+    - name: db-ca
+      secret:
+        secretName: {{ .DBCASecret | quote }}
+        items:
+        - key: ca.crt
+          path: ca.crt
+        defaultMode: 420
+{{- end }}
   volumeMounts:
     - name: account-iam-token
       mountPath: /var/run/secrets/tokens
@@ -387,6 +411,11 @@ spec:
     - name: account-iam-variables
       readOnly: true
       mountPath: /config/variables
+{{- if .DBCASecret }}
+    - name: db-ca
+      readOnly: true
+      mountPath: /config/db-ca
+{{- end }}
   envFrom:
     - configMapRef:
         name: account-iam-env-configmap-dev
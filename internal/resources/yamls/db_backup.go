@@ -0,0 +1,271 @@
+package yamls
+
+const DB_BACKUP_CRONJOB = `
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: account-iam-db-backup
+  labels:
+    by-squad: mcsp-user-management
+    for-product: all
+    component-name: iam-services
+spec:
+  schedule: {{ .BackupSchedule | quote }}
+  concurrencyPolicy: Forbid
+  successfulJobsHistoryLimit: 3
+  failedJobsHistoryLimit: 3
+  jobTemplate:
+    metadata:
+      labels:
+        operator.ibm.com/db-backup: account-iam
+    spec:
+      backoffLimit: 2
+      template:
+        metadata:
+          labels:
+            operator.ibm.com/db-backup: account-iam
+        spec:
+          restartPolicy: Never
+          initContainers:
+          - name: pg-dump
+            image: {{ .BackupImage | quote }}
+            command:
+            - /bin/bash
+            - -c
+            - |
+              set -e
+              pg_dump --format=custom --no-owner --no-privileges --file="/backup/${BACKUP_NAME}.dump.partial"
+              mv "/backup/${BACKUP_NAME}.dump.partial" "/backup/${BACKUP_NAME}.dump"
+            envFrom:
+            - secretRef:
+                name: account-iam-database-secret
+            env:
+            - name: BACKUP_NAME
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.labels['job-name']
+            - name: PGHOST
+              valueFrom:
+                secretKeyRef:
+                  name: account-iam-database-secret
+                  key: pg_jdbc_host
+            - name: PGPORT
+              valueFrom:
+                secretKeyRef:
+                  name: account-iam-database-secret
+                  key: pg_jdbc_port
+            - name: PGDATABASE
+              valueFrom:
+                secretKeyRef:
+                  name: account-iam-database-secret
+                  key: pg_db_name
+            - name: PGUSER
+              valueFrom:
+                secretKeyRef:
+                  name: account-iam-database-secret
+                  key: pg_db_user
+            - name: PGPASSWORD
+              valueFrom:
+                secretKeyRef:
+                  name: account-iam-database-secret
+                  key: pgPassword
+            resources: {{ .BackupResources }}
+            volumeMounts:
+            - name: backup
+              mountPath: /backup
+{{- if .DBCASecret }}
+            - name: db-ca
+              readOnly: true
+              mountPath: /config/db-ca
+{{- end }}
+          containers:
+{{- if .BackupS3Bucket }}
+          - name: upload
+            image: {{ .BackupS3Image | quote }}
+            command:
+            - /bin/sh
+            - -c
+            - |
+              set -e
+              mc alias set target "${S3_ENDPOINT}" "${AWS_ACCESS_KEY_ID}" "${AWS_SECRET_ACCESS_KEY}"
+              mc cp "/backup/${BACKUP_NAME}.dump" "target/${S3_BUCKET}/${S3_PREFIX}${BACKUP_NAME}.dump"
+            env:
+            - name: BACKUP_NAME
+              valueFrom:
+                fieldRef:
+                  fieldPath: metadata.labels['job-name']
+            - name: S3_ENDPOINT
+              value: {{ .BackupS3Endpoint | quote }}
+            - name: S3_BUCKET
+              value: {{ .BackupS3Bucket | quote }}
+            - name: S3_PREFIX
+              value: {{ .BackupS3Prefix | quote }}
+            - name: MC_CONFIG_DIR
+              value: /backup/.mc
+            - name: AWS_ACCESS_KEY_ID
+              valueFrom:
+                secretKeyRef:
+                  name: {{ .BackupS3Secret | quote }}
+                  key: AWS_ACCESS_KEY_ID
+            - name: AWS_SECRET_ACCESS_KEY
+              valueFrom:
+                secretKeyRef:
+                  name: {{ .BackupS3Secret | quote }}
+                  key: AWS_SECRET_ACCESS_KEY
+            resources: {{ .BackupResources }}
+            volumeMounts:
+            - name: backup
+              mountPath: /backup
+{{- else }}
+          - name: prune
+            image: {{ .BackupImage | quote }}
+            command:
+            - /bin/bash
+            - -c
+            - |
+              set -e
+              ls -1t /backup/*.dump | tail -n +$((BACKUP_KEEP + 1)) | xargs -r rm -f
+            env:
+            - name: BACKUP_KEEP
+              value: {{ .BackupKeep | quote }}
+            resources: {{ .BackupResources }}
+            volumeMounts:
+            - name: backup
+              mountPath: /backup
+{{- end }}
+          volumes:
+          - name: backup
+{{- if .BackupS3Bucket }}
+            emptyDir: {}
+{{- else }}
+            persistentVolumeClaim:
+              claimName: {{ .BackupPVC | quote }}
+{{- end }}
+{{- if .DBCASecret }}
+          - name: db-ca
+            secret:
+              secretName: {{ .DBCASecret | quote }}
+              items:
+              - key: ca.crt
+                path: ca.crt
+              defaultMode: 420
+{{- end }}
+`
+
+const DB_RESTORE_JOB = `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: restore-account-iam-db
+spec:
+  template:
+    metadata:
+      name: restore-account-iam-db
+    spec:
+      restartPolicy: Never
+{{- if .BackupS3Bucket }}
+      initContainers:
+      - name: download
+        image: {{ .BackupS3Image | quote }}
+        command:
+        - /bin/sh
+        - -c
+        - |
+          set -e
+          mc alias set target "${S3_ENDPOINT}" "${AWS_ACCESS_KEY_ID}" "${AWS_SECRET_ACCESS_KEY}"
+          mc cp "target/${S3_BUCKET}/${S3_PREFIX}${BACKUP_NAME}.dump" "/backup/${BACKUP_NAME}.dump"
+        env:
+        - name: BACKUP_NAME
+          value: {{ .BackupRestore | quote }}
+        - name: S3_ENDPOINT
+          value: {{ .BackupS3Endpoint | quote }}
+        - name: S3_BUCKET
+          value: {{ .BackupS3Bucket | quote }}
+        - name: S3_PREFIX
+          value: {{ .BackupS3Prefix | quote }}
+        - name: MC_CONFIG_DIR
+          value: /backup/.mc
+        - name: AWS_ACCESS_KEY_ID
+          valueFrom:
+            secretKeyRef:
+              name: {{ .BackupS3Secret | quote }}
+              key: AWS_ACCESS_KEY_ID
+        - name: AWS_SECRET_ACCESS_KEY
+          valueFrom:
+            secretKeyRef:
+              name: {{ .BackupS3Secret | quote }}
+              key: AWS_SECRET_ACCESS_KEY
+        resources: {{ .BackupResources }}
+        volumeMounts:
+        - name: backup
+          mountPath: /backup
+{{- end }}
+      containers:
+      - name: pg-restore
+        image: {{ .BackupImage | quote }}
+        command:
+        - /bin/bash
+        - -c
+        - |
+          set -e
+          pg_restore --clean --if-exists --no-owner --no-privileges --single-transaction --exit-on-error \
+            --dbname="${PGDATABASE}" "/backup/${BACKUP_NAME}.dump"
+        envFrom:
+        - secretRef:
+            name: account-iam-database-secret
+        env:
+        - name: BACKUP_NAME
+          value: {{ .BackupRestore | quote }}
+        - name: PGHOST
+          valueFrom:
+            secretKeyRef:
+              name: account-iam-database-secret
+              key: pg_jdbc_host
+        - name: PGPORT
+          valueFrom:
+            secretKeyRef:
+              name: account-iam-database-secret
+              key: pg_jdbc_port
+        - name: PGDATABASE
+          valueFrom:
+            secretKeyRef:
+              name: account-iam-database-secret
+              key: pg_db_name
+        - name: PGUSER
+          valueFrom:
+            secretKeyRef:
+              name: account-iam-database-secret
+              key: pg_db_user
+        - name: PGPASSWORD
+          valueFrom:
+            secretKeyRef:
+              name: account-iam-database-secret
+              key: pgPassword
+        resources: {{ .BackupResources }}
+        volumeMounts:
+        - name: backup
+          mountPath: /backup
+{{- if .DBCASecret }}
+        - name: db-ca
+          readOnly: true
+          mountPath: /config/db-ca
+{{- end }}
+      volumes:
+      - name: backup
+{{- if .BackupS3Bucket }}
+        emptyDir: {}
+{{- else }}
+        persistentVolumeClaim:
+          claimName: {{ .BackupPVC | quote }}
+{{- end }}
+{{- if .DBCASecret }}
+      - name: db-ca
+        secret:
+          secretName: {{ .DBCASecret | quote }}
+          items:
+          - key: ca.crt
+            path: ca.crt
+          defaultMode: 420
+{{- end }}
+  backoffLimit: 0
+`
@@ -12,35 +12,223 @@ spec:
     spec:
       containers:
       - name: postgres
-        image: docker-na-public.artifactory.swg-devops.com/hyc-cloud-private-integration-docker-local/ibmcom/mcsp-utils:latest
-        command: ["/bin/bash", "/db-init/create_db.sh"]
+        image: {{ .DBBootstrapImage | quote }}
+        command:
+        - /bin/bash
+        - -c
+        - |
+          set -e
+          export PGPORT=5432 PGDATABASE=postgres
+          export PGUSER=$(cat /psql-credentials/username) PGPASSWORD=$(cat /psql-credentials/password)
+          psql -v ON_ERROR_STOP=1 -v user="${DB_USER}" -v password="$(cat /db-password/password)" -v name="${DB_NAME}" <<'SQL'
+          SELECT format('CREATE ROLE %I LOGIN', :'user') WHERE NOT EXISTS (SELECT FROM pg_roles WHERE rolname = :'user')\gexec
+          ALTER ROLE :"user" WITH LOGIN PASSWORD :'password';
+          SELECT format('CREATE DATABASE %I OWNER %I', :'name', :'user') WHERE NOT EXISTS (SELECT FROM pg_database WHERE datname = :'name')\gexec
+          SQL
+          psql -v ON_ERROR_STOP=1 -d "${DB_NAME}" -v user="${DB_USER}" -v schema="${DB_SCHEMA}" <<'SQL'
+          CREATE SCHEMA IF NOT EXISTS :"schema" AUTHORIZATION :"user";
+          SQL
+        env:
+        - name: PGHOST
+          value: {{ .DBHost | quote }}
+        - name: DB_NAME
+          value: {{ .DBName | quote }}
+        - name: DB_SCHEMA
+          value: {{ .DBSchema | quote }}
+        - name: DB_USER
+          value: {{ .DBUser | quote }}
+{{- if .DBSSLMode }}
+        - name: PGSSLMODE
+          value: {{ .DBSSLMode | quote }}
+{{- end }}
+{{- if .DBCASecret }}
+        - name: PGSSLROOTCERT
+          value: /config/db-ca/ca.crt
+{{- end }}
+        resources: {{ .DBBootstrapResources }}
         volumeMounts:
         - name: psql-credentials
           mountPath: /psql-credentials
         - name: db-password
           mountPath: /db-password
-        - name: data-volume
-          mountPath: /data
+{{- if .DBCASecret }}
+        - name: db-ca
+          readOnly: true
+          mountPath: /config/db-ca
+{{- end }}
       restartPolicy: OnFailure
       volumes:
       - name: psql-credentials
         secret:
-          secretName: common-service-db-superuser
+          secretName: {{ .DBSuperuserSecret | quote }}
           items:
           - key: username
             path: username
           - key: password
             path: password
-          defaultMode: 420  
+          defaultMode: 420
       - name: db-password
         secret:
           secretName: user-mgmt-bootstrap
           items:
           - key: PGPassword
             path: password
-          defaultMode: 420  
-      - name: data-volume
-        emptyDir: {}      
+          defaultMode: 420
+{{- if .DBCASecret }}
+      - name: db-ca
+        secret:
+          secretName: {{ .DBCASecret | quote }}
+          items:
+          - key: ca.crt
+            path: ca.crt
+          defaultMode: 420
+{{- end }}
   backoffLimit: 4
 
 `
+
+const DB_DROP_JOB = `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: drop-account-iam-db
+spec:
+  template:
+    metadata:
+      name: drop-account-iam-db
+    spec:
+      containers:
+      - name: postgres
+        image: {{ .DBBootstrapImage | quote }}
+        command:
+        - /bin/bash
+        - -c
+        - |
+          set -e
+          export PGPORT=5432 PGDATABASE=postgres
+          export PGUSER=$(cat /psql-credentials/username) PGPASSWORD=$(cat /psql-credentials/password)
+          psql -v ON_ERROR_STOP=1 -c "DROP DATABASE IF EXISTS \"${DB_NAME}\" WITH (FORCE)"
+          psql -v ON_ERROR_STOP=1 -c "DROP ROLE IF EXISTS \"${DB_USER}\""
+        env:
+        - name: PGHOST
+          value: {{ .DBHost | quote }}
+        - name: DB_NAME
+          value: {{ .DBName | quote }}
+        - name: DB_USER
+          value: {{ .DBUser | quote }}
+{{- if .DBSSLMode }}
+        - name: PGSSLMODE
+          value: {{ .DBSSLMode | quote }}
+{{- end }}
+{{- if .DBCASecret }}
+        - name: PGSSLROOTCERT
+          value: /config/db-ca/ca.crt
+{{- end }}
+        resources: {{ .DBBootstrapResources }}
+        volumeMounts:
+        - name: psql-credentials
+          mountPath: /psql-credentials
+{{- if .DBCASecret }}
+        - name: db-ca
+          readOnly: true
+          mountPath: /config/db-ca
+{{- end }}
+      restartPolicy: OnFailure
+      volumes:
+      - name: psql-credentials
+        secret:
+          secretName: {{ .DBSuperuserSecret | quote }}
+          items:
+          - key: username
+            path: username
+          - key: password
+            path: password
+          defaultMode: 420
+{{- if .DBCASecret }}
+      - name: db-ca
+        secret:
+          secretName: {{ .DBCASecret | quote }}
+          items:
+          - key: ca.crt
+            path: ca.crt
+          defaultMode: 420
+{{- end }}
+  backoffLimit: 4
+`
+
+const DB_ROTATE_PASSWORD_JOB = `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: rotate-account-iam-db-password
+spec:
+  template:
+    metadata:
+      name: rotate-account-iam-db-password
+    spec:
+      containers:
+      - name: postgres
+        image: {{ .DBBootstrapImage | quote }}
+        command:
+        - /bin/bash
+        - -c
+        - |
+          set -e
+          export PGPORT=5432 PGDATABASE=postgres
+          export PGUSER=$(cat /psql-credentials/username) PGPASSWORD=$(cat /psql-credentials/password)
+          psql -v ON_ERROR_STOP=1 -v user="${DB_USER}" -v password="$(cat /db-password/password)" <<'SQL'
+          ALTER ROLE :"user" WITH PASSWORD :'password';
+          SQL
+        env:
+        - name: PGHOST
+          value: {{ .DBHost | quote }}
+        - name: DB_USER
+          value: {{ .DBUser | quote }}
+{{- if .DBSSLMode }}
+        - name: PGSSLMODE
+          value: {{ .DBSSLMode | quote }}
+{{- end }}
+{{- if .DBCASecret }}
+        - name: PGSSLROOTCERT
+          value: /config/db-ca/ca.crt
+{{- end }}
+        resources: {{ .DBBootstrapResources }}
+        volumeMounts:
+        - name: psql-credentials
+          mountPath: /psql-credentials
+        - name: db-password
+          mountPath: /db-password
+{{- if .DBCASecret }}
+        - name: db-ca
+          readOnly: true
+          mountPath: /config/db-ca
+{{- end }}
+      restartPolicy: OnFailure
+      volumes:
+      - name: psql-credentials
+        secret:
+          secretName: {{ .DBSuperuserSecret | quote }}
+          items:
+          - key: username
+            path: username
+          - key: password
+            path: password
+          defaultMode: 420
+      - name: db-password
+        secret:
+          secretName: user-mgmt-bootstrap
+          items:
+          - key: PGPasswordPending
+            path: password
+          defaultMode: 420
+{{- if .DBCASecret }}
+      - name: db-ca
+        secret:
+          secretName: {{ .DBCASecret | quote }}
+          items:
+          - key: ca.crt
+            path: ca.crt
+          defaultMode: 420
+{{- end }}
+  backoffLimit: 4
+`
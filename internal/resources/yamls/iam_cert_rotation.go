@@ -21,7 +21,7 @@ metadata:
     component-name: iam-services
     for-product: all
 spec:
-  replicas: 1
+  replicas: {{ .CertRotationReplicas }}
   selector:
     matchLabels:
       control-plane: iam-cert-rotation-manager
@@ -42,13 +42,7 @@ spec:
       securityContext:
         runAsNonRoot: true
       containers:
-        - resources:
-            limits:
-              cpu: 5m
-              memory: 64Mi
-            requests:
-              cpu: 2m
-              memory: 32Mi
+        - resources: {{ .CertRotationResources }}
           readinessProbe:
             httpGet:
               path: /readyz
@@ -86,8 +80,7 @@ spec:
             allowPrivilegeEscalation: false
           imagePullPolicy: Always
           terminationMessagePolicy: File
-          image: >-
-            icr.io/automation-saas-platform/access-management/iam-cert-rotation:20240306103454-main-86f22aa63ce252c4add52c8c7bf11ff24c430764
+          image: {{ .CertRotationImage | quote }}
       serviceAccount: msp-iam-cert-rotation-sa
       dnsPolicy: ClusterFirst
   strategy:
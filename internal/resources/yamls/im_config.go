@@ -4,7 +4,6 @@ var IMConfigYamls = []string{
 	IM_CONFIG_ROLE,
 	IM_CONFIG_ROLE_BINDING,
 	IM_CONFIG_SA,
-	IM_CONFIG_JOB,
 }
 
 var IM_CONFIG_JOB = `
@@ -22,9 +21,10 @@ spec:
     spec:
       containers:
       - name: mcsp-im-config-job
-        image: docker-na-public.artifactory.swg-devops.com/hyc-cloud-private-scratch-docker-local/ibmcom/mcsp-im-config-job-amd64:f2a2456
+        image: {{ .IMConfigImage | quote }}
         command: ["./mcsp-im-config-job"]
         imagePullPolicy: Always
+        resources: {{ .IMConfigResources }}
         securityContext:
           allowPrivilegeEscalation: false
           capabilities:
@@ -37,11 +37,11 @@ spec:
           - name: LOG_LEVEL
             value: debug
           - name: NAMESPACE
-            value: {{ .AccountIAMNamespace }}
+            value: {{ .AccountIAMNamespace | quote }}
           - name: IM_HOST_URL
-            value: {{ .IAMHOSTURL }}
+            value: {{ .IAMHOSTURL | quote }}
           - name: ACCOUNT_IAM_URL
-            value: {{ .AccountIAMURL }}
+            value: {{ .AccountIAMURL | quote }}
       serviceAccountName: mcsp-im-config-sa
       restartPolicy: OnFailure
 `
@@ -86,3 +86,96 @@ roleRef:
   name: mcsp-im-config-role
   apiGroup: rbac.authorization.k8s.io
 `
+
+var REGISTER_OIDC_CLIENT_JOB = `
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: account-iam-oidc-client-registration
+  labels:
+    app: account-iam-oidc-client-registration
+spec:
+  template:
+    metadata:
+      labels:
+        app: account-iam-oidc-client-registration
+    spec:
+      containers:
+      - name: register
+        image: {{ .OIDCRegistrationImage | quote }}
+        resources: {{ .IMConfigResources }}
+        securityContext:
+          allowPrivilegeEscalation: false
+          capabilities:
+            drop:
+              - ALL
+          runAsNonRoot: true
+          seccompProfile:
+            type: RuntimeDefault
+        command:
+        - /bin/bash
+        - -c
+        - |
+          set -e
+          URL=https://platform-auth-service:9443/oidc/endpoint/OP/registration
+          CURL="curl -sSf --cacert /ca/ca.crt -u oauthadmin:$(cat /oidc-credentials/secret) -H Content-Type:application/json -o /dev/null"
+          BODY=$(cat <<JSON
+          {
+            "client_id": "${CLIENT_ID}",
+            "client_secret": "${CLIENT_SECRET}",
+            "client_name": "account-iam",
+            "application_type": "web",
+            "token_endpoint_auth_method": "client_secret_basic",
+            "scope": "openid profile email",
+            "preauthorized_scope": "openid profile email",
+            "grant_types": ["authorization_code", "client_credentials", "refresh_token", "urn:ietf:params:oauth:grant-type:jwt-bearer"],
+            "response_types": ["code"],
+            "redirect_uris": ["${ACCOUNT_IAM_URL}/"],
+            "trusted_uri_prefixes": ["${ACCOUNT_IAM_URL}/"],
+            "introspect_tokens": true
+          }
+          JSON
+          )
+          if $CURL "${URL}/${CLIENT_ID}"; then
+            echo "Updating OIDC client ${CLIENT_ID}"
+            $CURL -X PUT -d "${BODY}" "${URL}/${CLIENT_ID}"
+          else
+            echo "Registering OIDC client ${CLIENT_ID}"
+            $CURL -X POST -d "${BODY}" "${URL}"
+          fi
+        env:
+          - name: ACCOUNT_IAM_URL
+            value: {{ .AccountIAMURL | quote }}
+          - name: CLIENT_ID
+            valueFrom:
+              secretKeyRef:
+                name: user-mgmt-bootstrap
+                key: ClientID
+          - name: CLIENT_SECRET
+            valueFrom:
+              secretKeyRef:
+                name: user-mgmt-bootstrap
+                key: ClientSecret
+        volumeMounts:
+        - name: oidc-credentials
+          mountPath: /oidc-credentials
+        - name: ca
+          mountPath: /ca
+      restartPolicy: OnFailure
+      volumes:
+      - name: oidc-credentials
+        secret:
+          secretName: platform-oidc-credentials
+          items:
+          - key: OAUTH2_CLIENT_REGISTRATION_SECRET
+            path: secret
+          defaultMode: 420
+      - name: ca
+        secret:
+          secretName: cs-ca-certificate-secret
+          items:
+          - key: ca.crt
+            path: ca.crt
+          defaultMode: 420
+  backoffLimit: 4
+`
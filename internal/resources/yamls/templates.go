@@ -0,0 +1,56 @@
+package yamls
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// funcs are the helpers the manifest templates render their values with
+var funcs = template.FuncMap{
+	// quote renders the value as a double quoted YAML scalar, so a value
+	// with YAML special characters, or one which looks like a number or a
+	// boolean, stays the string it is
+	"quote": func(value interface{}) (string, error) {
+		quoted, err := json.Marshal(fmt.Sprint(value))
+		return string(quoted), err
+	},
+}
+
+// Templates are the manifests rendered with the template data of the
+// operator, each parsed once at startup
+func Templates() []string {
+	templates := append([]string{}, APP_SECRETS...)
+	templates = append(templates, APP_CONFIGS...)
+	templates = append(templates, APP_WORKLOADS...)
+	templates = append(templates, CertRotationYamls...)
+	templates = append(templates, IMConfigYamls...)
+	return append(templates,
+		DB_MIGRATION_MCSPID,
+		DB_MIGRATION_MCSPID_SA,
+		DB_BOOTSTRAP_JOB,
+		DB_DROP_JOB,
+		DB_ROTATE_PASSWORD_JOB,
+		DB_BACKUP_CRONJOB,
+		DB_RESTORE_JOB,
+		IM_CONFIG_JOB,
+		REGISTER_OIDC_CLIENT_JOB,
+	)
+}
+
+var parsed = map[string]*template.Template{}
+
+func init() {
+	for i, manifest := range Templates() {
+		parsed[manifest] = template.Must(template.New(fmt.Sprintf("manifest-%d", i)).Funcs(funcs).Parse(manifest))
+	}
+}
+
+// Template returns the parsed template of the manifest
+func Template(manifest string) (*template.Template, error) {
+	t, ok := parsed[manifest]
+	if !ok {
+		return nil, fmt.Errorf("manifest is not one of the templates parsed at startup")
+	}
+	return t, nil
+}